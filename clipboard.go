@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands 各平台写入剪贴板的命令，按顺序尝试第一个可用的
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	default:
+		return [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+}
+
+// copyToClipboard 通过系统命令将text写入剪贴板
+func copyToClipboard(text string) error {
+	for _, args := range clipboardCommands() {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s 执行失败: %v %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return errors.New("未找到可用的剪贴板命令")
+}
+
+// selectedRules 返回要复制或导出的规则：有逐个文件的规则时返回这些规则，否则优先返回（各季的）批量规则
+func selectedRules(r *report) []rule {
+	switch {
+	case len(r.FileRules) > 0:
+		return r.FileRules
+	case len(r.SeasonRules) > 0:
+		rules := make([]rule, 0, len(r.SeasonRules))
+		for _, sr := range r.SeasonRules {
+			rules = append(rules, sr.Rule)
+		}
+		return rules
+	case r.BatchRule != nil:
+		return []rule{*r.BatchRule}
+	case r.Rule != nil:
+		return []rule{*r.Rule}
+	}
+	return nil
+}
+
+// clipboardRules 返回要复制的规则，每行一条，格式为“被替换词 => 替换词”
+func clipboardRules(r *report) string {
+	var b strings.Builder
+	for _, r := range selectedRules(r) {
+		fmt.Fprintf(&b, "%s => %s\n", r.Pattern, r.Replacement)
+	}
+	return b.String()
+}
+
+// copyRules 将生成的规则复制到剪贴板，无法访问剪贴板时改为输出到标准输出
+func copyRules(r *report) {
+	text := clipboardRules(r)
+	if text == "" {
+		return
+	}
+	if err := copyToClipboard(text); err != nil {
+		warnf("无法写入剪贴板（%v），规则输出如下", err)
+		fmt.Print(text)
+		return
+	}
+	fmt.Printf("\n已将%d条规则复制到剪贴板\n", strings.Count(text, "\n"))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	TMDBApiKey string `json:"tmdb_api_key"`
+	Language   string `json:"language,omitempty"` // TMDB返回内容的语言，默认为 zh-CN
+	Template   string `json:"template,omitempty"` // 默认文件名模板，优先级低于 -template 和 -preset
+	BaseURL    string `json:"base_url,omitempty"` // TMDB API地址，可指向代理或镜像
+
+	// -providers 查询观看渠道的地区代码，如 US、CN，为空时取 language 中的地区（zh-CN 为 CN）
+	ProviderRegion string `json:"provider_region,omitempty"`
+
+	// 保留文件名中的 4K/8K 标签，默认统一为 2160P/4320P
+	KeepResolutionLabel bool `json:"keep_resolution_label,omitempty"`
+
+	// 输出中以 第01季第01集 代替 S01E01，对所有模板（包括 -template 和预设）生效
+	LocalizedEpisode bool `json:"localized_episode,omitempty"`
+
+	// 追加到内置识别规则之后的自定义正则，季集规则需包含季、集两个分组，仅集数规则需包含一个分组
+	ExtraSeasonEpisodePatterns []string `json:"extra_season_episode_patterns,omitempty"`
+	ExtraEpisodeOnlyPatterns   []string `json:"extra_episode_only_patterns,omitempty"`
+}
+
+// defaultConfigPath 默认的配置文件路径（相对于工作目录），可通过 -config 指定
+const defaultConfigPath = "custom-recognition.config"
+
+// errConfigIsDir 配置文件路径是一个目录，此时不读取也不保存配置文件，本次运行仅使用环境变量和命令行参数
+var errConfigIsDir = errors.New("配置文件路径是一个目录")
+
+// configDirError 返回配置文件路径是目录时的错误，附带修复建议
+func configDirError(configPath string) error {
+	return fmt.Errorf("%w：%s，请删除或重命名该目录，或通过 -config 指定其他路径", errConfigIsDir, configPath)
+}
+
+func readConfig(configPath string) (*Config, error) {
+	if stat, err := os.Stat(configPath); err == nil && stat.IsDir() {
+		return nil, configDirError(configPath)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记事本等编辑器保存时可能带有UTF-8 BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, configSyntaxError(configPath, data, err)
+	}
+
+	return &config, nil
+}
+
+// configSyntaxError 将JSON解析错误转换为带行号的提示
+func configSyntaxError(configPath string, data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return fmt.Errorf("配置文件 %s 格式错误: %v", configPath, err)
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	return fmt.Errorf("配置文件 %s 第%d行格式错误: %v", configPath, line, err)
+}
+
+// envPrefix 环境变量前缀，如 CR_TMDB_API_KEY
+const envPrefix = "CR_"
+
+// withEnv 返回用环境变量覆盖后的配置，不修改config本身，config为nil时只使用环境变量；
+// 自定义识别规则每行一条
+func withEnv(config *Config) *Config {
+	merged := Config{}
+	if config != nil {
+		merged = *config
+	}
+
+	strs := map[string]*string{
+		"TMDB_API_KEY":    &merged.TMDBApiKey,
+		"LANGUAGE":        &merged.Language,
+		"TEMPLATE":        &merged.Template,
+		"BASE_URL":        &merged.BaseURL,
+		"PROVIDER_REGION": &merged.ProviderRegion,
+	}
+	for name, field := range strs {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			*field = v
+		}
+	}
+
+	bools := map[string]*bool{
+		"KEEP_RESOLUTION_LABEL": &merged.KeepResolutionLabel,
+		"LOCALIZED_EPISODE":     &merged.LocalizedEpisode,
+	}
+	for name, field := range bools {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			if b, err := strconv.ParseBool(v); err == nil {
+				*field = b
+			}
+		}
+	}
+
+	lists := map[string]*[]string{
+		"EXTRA_SEASON_EPISODE_PATTERNS": &merged.ExtraSeasonEpisodePatterns,
+		"EXTRA_EPISODE_ONLY_PATTERNS":   &merged.ExtraEpisodeOnlyPatterns,
+	}
+	for name, field := range lists {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			*field = nil
+			for _, line := range strings.Split(v, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					*field = append(*field, line)
+				}
+			}
+		}
+	}
+	return &merged
+}
+
+// dumpConfig 以JSON格式输出实际生效的配置，API密钥已隐藏
+func dumpConfig(w io.Writer, config Config) error {
+	if config.TMDBApiKey != "" {
+		config.TMDBApiKey = "REDACTED"
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(config)
+}
+
+// saveConfig 将配置写入configPath，路径是目录或不可写入时返回带修复建议的错误，不影响本次运行
+func saveConfig(configPath string, config *Config) error {
+	if stat, err := os.Stat(configPath); err == nil && stat.IsDir() {
+		return configDirError(configPath)
+	}
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("配置文件位置不可写入: %v，请通过 -config 指定可写入的路径", err)
+	}
+	return nil
+}
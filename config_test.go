@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigBOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultConfigPath)
+	writeFile(t, path, "\xef\xbb\xbf{\n    \"tmdb_api_key\": \"key\",\n    \"language\": \"en-US\"\n}\n")
+	config, err := readConfig(path)
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+	if config.TMDBApiKey != "key" || config.Language != "en-US" {
+		t.Errorf("readConfig() = %+v, want key and en-US", config)
+	}
+}
+
+func TestReadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"缺少逗号", "{\n    \"tmdb_api_key\": \"key\"\n    \"language\": \"en-US\"\n}\n", "第3行格式错误"},
+		{"带BOM时的行号", "\xef\xbb\xbf{\n    \"tmdb_api_key\": \"key\",\n\n    \"language\": en\n}\n", "第4行格式错误"},
+		{"类型错误", "{\n    \"tmdb_api_key\": \"key\",\n    \"keep_resolution_label\": \"yes\"\n}\n", "第3行格式错误"},
+		{"内容不完整", "{\n    \"tmdb_api_key\": \"key\"", "格式错误"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), defaultConfigPath)
+		writeFile(t, path, tt.content)
+		_, err := readConfig(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: readConfig() error = %v, want %q with the path", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestReadConfigPaths(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := readConfig(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("readConfig() for a missing file error = %v, want not exist", err)
+	}
+	if _, err := readConfig(dir); !errors.Is(err, errConfigIsDir) {
+		t.Errorf("readConfig() for a directory error = %v, want errConfigIsDir", err)
+	}
+}
+
+func TestDumpConfigRedactsAPIKey(t *testing.T) {
+	for _, key := range []string{"0123456789abcdef", ""} {
+		var out strings.Builder
+		config := Config{TMDBApiKey: key, Language: "en-US", Template: "{title} <{year}>"}
+		if err := dumpConfig(&out, config); err != nil {
+			t.Fatalf("dumpConfig() error = %v", err)
+		}
+		got := out.String()
+		if key != "" && (strings.Contains(got, key) || !strings.Contains(got, `"tmdb_api_key": "REDACTED"`)) {
+			t.Errorf("dumpConfig() = %s, want the API key redacted", got)
+		}
+		if key == "" && !strings.Contains(got, `"tmdb_api_key": ""`) {
+			t.Errorf("dumpConfig() = %s, want an empty API key to stay empty", got)
+		}
+		if !strings.Contains(got, `"template": "{title} <{year}>"`) || !strings.Contains(got, `"language": "en-US"`) {
+			t.Errorf("dumpConfig() = %s, want the other settings unchanged", got)
+		}
+		if config.TMDBApiKey != key {
+			t.Errorf("dumpConfig() modified the caller's config")
+		}
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	config := &Config{TMDBApiKey: "file-key", Language: "en-US", KeepResolutionLabel: true, ExtraEpisodeOnlyPatterns: []string{`#(\d+)`}}
+	t.Setenv("CR_TMDB_API_KEY", "env-key")
+	t.Setenv("CR_TEMPLATE", "")
+	t.Setenv("CR_LOCALIZED_EPISODE", "true")
+	t.Setenv("CR_KEEP_RESOLUTION_LABEL", "maybe")
+	t.Setenv("CR_EXTRA_SEASON_EPISODE_PATTERNS", " (\\d+)季(\\d+)话 \n\n第(\\d+)期(\\d+)\n")
+	t.Setenv("CR_EXTRA_EPISODE_ONLY_PATTERNS", "")
+
+	got := withEnv(config)
+	if got.TMDBApiKey != "env-key" || got.Language != "en-US" || got.Template != "" {
+		t.Errorf("withEnv() strings = %q, %q, %q, want env-key, en-US and an empty template", got.TMDBApiKey, got.Language, got.Template)
+	}
+	// 无法解析的布尔值不覆盖配置文件中的值
+	if !got.LocalizedEpisode || !got.KeepResolutionLabel {
+		t.Errorf("withEnv() bools = %v, %v, want true, true", got.LocalizedEpisode, got.KeepResolutionLabel)
+	}
+	if want := []string{`(\d+)季(\d+)话`, `第(\d+)期(\d+)`}; !slices.Equal(got.ExtraSeasonEpisodePatterns, want) {
+		t.Errorf("withEnv() ExtraSeasonEpisodePatterns = %q, want %q", got.ExtraSeasonEpisodePatterns, want)
+	}
+	if got.ExtraEpisodeOnlyPatterns != nil {
+		t.Errorf("withEnv() ExtraEpisodeOnlyPatterns = %q, want an empty variable to clear the list", got.ExtraEpisodeOnlyPatterns)
+	}
+	if config.TMDBApiKey != "file-key" || config.LocalizedEpisode {
+		t.Errorf("withEnv() modified the config read from the file: %+v", config)
+	}
+
+	if got := withEnv(nil); got.TMDBApiKey != "env-key" || !got.LocalizedEpisode {
+		t.Errorf("withEnv(nil) = %+v, want the environment values", got)
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, defaultConfigPath)
+	want := &Config{TMDBApiKey: "key", Language: "en-US"}
+	if err := saveConfig(path, want); err != nil {
+		t.Fatalf("saveConfig() error = %v", err)
+	}
+	if got, err := readConfig(path); err != nil || got.TMDBApiKey != want.TMDBApiKey || got.Language != want.Language {
+		t.Errorf("readConfig() after saveConfig() = %+v, %v, want %+v", got, err, want)
+	}
+
+	// 路径是目录
+	if err := saveConfig(dir, want); !errors.Is(err, errConfigIsDir) || !strings.Contains(err.Error(), "-config") {
+		t.Errorf("saveConfig() to a directory error = %v, want errConfigIsDir with the -config hint", err)
+	}
+
+	// 所在目录不可写入
+	readOnly := filepath.Join(dir, "readonly")
+	if err := os.Mkdir(readOnly, 0500); err != nil {
+		t.Fatal(err)
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root用户不受目录权限限制")
+	}
+	err := saveConfig(filepath.Join(readOnly, defaultConfigPath), want)
+	if err == nil || !strings.Contains(err.Error(), "不可写入") || !strings.Contains(err.Error(), "-config") {
+		t.Errorf("saveConfig() to an unwritable directory error = %v, want the -config hint", err)
+	}
+}
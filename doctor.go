@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// runDoctor 逐项检查运行环境并输出 PASS/FAIL 和修复建议，configErr为读取配置文件时的错误；全部通过时返回true
+func runDoctor(ctx context.Context, timeout time.Duration, configPath string, fileConfig *Config, configErr error) bool {
+	ok := true
+	check := func(name string, err error, hint string) {
+		if err == nil {
+			fmt.Printf("[PASS] %s\n", name)
+			return
+		}
+		ok = false
+		fmt.Printf("[FAIL] %s: %v\n", name, err)
+		if hint != "" {
+			fmt.Printf("       %s\n", hint)
+		}
+	}
+
+	switch {
+	case os.IsNotExist(configErr):
+		fmt.Println("[INFO] 配置文件不存在，将仅使用环境变量；首次输入API密钥后会自动创建")
+	case errors.Is(configErr, errConfigIsDir):
+		check("配置文件可读取", configErr, "")
+	default:
+		check("配置文件可读取", configErr, "请检查 "+configPath+" 的JSON格式和文件权限")
+	}
+
+	config := withEnv(fileConfig)
+	client := newClient(config, "")
+
+	// 不带API密钥访问，返回任何HTTP状态（通常是401）都说明网络可以连通
+	err := request(ctx, timeout, func(ctx context.Context) error {
+		return client.Configuration(ctx)
+	})
+	var statusErr *tmdb.StatusError
+	if errors.As(err, &statusErr) {
+		err = nil
+	}
+	check("可以连接 "+client.BaseURL, err, "请检查网络或代理设置，也可以通过配置项 base_url 或环境变量 CR_BASE_URL 指定可访问的地址")
+
+	if config.TMDBApiKey == "" {
+		check("已配置API密钥", errors.New("未找到API密钥"), "请在配置文件中设置 tmdb_api_key，或设置环境变量 CR_TMDB_API_KEY")
+	} else {
+		client.APIKey = config.TMDBApiKey
+		err := request(ctx, timeout, func(ctx context.Context) error {
+			return client.Configuration(ctx)
+		})
+		check("API密钥有效", err, "请在 https://www.themoviedb.org/settings/api 确认API密钥（v3）是否正确")
+	}
+
+	check("配置文件所在目录可写入（用于保存配置文件）", checkWritable(filepath.Dir(configPath)), "请通过 -config 指定可写入的路径，或通过环境变量提供配置")
+
+	return ok
+}
+
+// checkWritable 在dir中创建并删除一个临时文件，检查是否有写入权限
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".custom-recognition-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// exportRules 将规则以制表符分隔的文本写入文件，每行一条，格式为“被替换词<TAB>替换词”，没有表头，
+// 便于外部批量重命名工具导入。被替换词中的制表符转义为正则中等价的 \t，替换词中不能包含制表符或换行
+func exportRules(path string, rules []rule) error {
+	var b bytes.Buffer
+	for _, r := range rules {
+		if strings.ContainsAny(r.Replacement, "\t\r\n") || strings.ContainsAny(r.Pattern, "\r\n") {
+			return fmt.Errorf("规则 %q 中包含换行或制表符，无法导出", r.Pattern)
+		}
+		fmt.Fprintf(&b, "%s\t%s\n", strings.ReplaceAll(r.Pattern, "\t", `\t`), r.Replacement)
+	}
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
@@ -1,3 +1,3 @@
-module custom-recognition
+module github.com/Harry-zy/custom-recognition
 
 go 1.22.5
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// logLevel 日志级别，数值越大输出越详细
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+var logLevelNames = map[string]logLevel{
+	"error": levelError,
+	"warn":  levelWarn,
+	"info":  levelInfo,
+	"debug": levelDebug,
+}
+
+// logLevelList 返回可用的日志级别，用于参数说明和错误提示
+func logLevelList() []string {
+	names := make([]string, 0, len(logLevelNames))
+	for name := range logLevelNames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return logLevelNames[names[i]] < logLevelNames[names[j]] })
+	return names
+}
+
+// 当前的日志级别和输出位置；info、debug输出到标准输出，warn、error输出到标准错误；
+// 生成的规则、预览等结果直接输出到标准输出，不受日志级别影响
+var (
+	currentLevel           = levelInfo
+	logStdout    io.Writer = os.Stdout
+	logStderr    io.Writer = os.Stderr
+)
+
+func logf(level logLevel, format string, args ...any) {
+	if level > currentLevel {
+		return
+	}
+	w, prefix := logStdout, ""
+	switch level {
+	case levelError:
+		w, prefix = logStderr, "错误："
+	case levelWarn:
+		w, prefix = logStderr, "警告："
+	case levelDebug:
+		prefix = "调试："
+	}
+	fmt.Fprintf(w, prefix+format+"\n", args...)
+}
+
+func errorf(format string, args ...any) { logf(levelError, format, args...) }
+func warnf(format string, args ...any)  { logf(levelWarn, format, args...) }
+func infof(format string, args ...any)  { logf(levelInfo, format, args...) }
+func debugf(format string, args ...any) { logf(levelDebug, format, args...) }
+
+// levelWriter 返回该级别启用时按该级别输出（带有“警告：”等前缀）的io.Writer，未启用时返回nil，用于 recognition.Warnings 这类io.Writer
+func levelWriter(level logLevel) io.Writer {
+	if level > currentLevel {
+		return nil
+	}
+	return logWriter(level)
+}
+
+// logWriter 将每次写入的内容作为一条该级别的日志输出
+type logWriter logLevel
+
+func (l logWriter) Write(p []byte) (int, error) {
+	logf(logLevel(l), "%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -2,437 +2,940 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
-)
 
-const (
-	baseURL = "https://api.tmdb.org/3"
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
 )
 
-const (
-	MediaTypeMovie = "movie"
-	MediaTypeTV    = "tv"
+// 构建信息，通过 -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..." 注入
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
 )
 
-type MovieResponse struct {
-	Title        string `json:"title"`
-	Name         string `json:"name"`           // 电视剧标题
-	ReleaseDate  string `json:"release_date"`   // 电影日期
-	FirstAirDate string `json:"first_air_date"` // 电视剧日期
-	ID           int    `json:"id"`
-}
+// stdin 所有提示共用的输入缓冲，每次提示都新建Reader时，管道输入中已读入缓冲的后续行会丢失
+var stdin = bufio.NewReader(os.Stdin)
 
-type FileInfo struct {
-	FullMatch   string
-	Season      string
-	Episode     string
-	VideoFormat string
-}
+// noPrompt -interactive=false 时为true，所有提示都不再读取标准输入
+var noPrompt bool
 
-type Config struct {
-	TMDBApiKey string `json:"tmdb_api_key"`
+// readLine 输出提示并读取一行输入，标准输入已结束且没有读到任何内容时返回 io.EOF；
+// 非交互模式下不读取标准输入，直接返回 io.EOF
+func readLine(prompt string) (string, error) {
+	if noPrompt {
+		return "", io.EOF
+	}
+	fmt.Print(prompt)
+	input, err := stdin.ReadString('\n')
+	if err == io.EOF && input != "" {
+		err = nil
+	}
+	return strings.TrimSpace(input), err
 }
 
 func getInput(prompt string) string {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print(prompt)
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+	input, _ := readLine(prompt)
+	return input
 }
 
-func getIntInput(prompt string) (int, error) {
-	input := getInput(prompt)
-	return strconv.Atoi(input)
+// requireValues 非交互模式下列出缺少的必需值及其提供方式并退出，没有缺少时直接返回
+func requireValues(missing []string) {
+	if len(missing) == 0 {
+		return
+	}
+	errorf("非交互模式（-interactive=false）下缺少以下必需的值，请通过命令行参数、环境变量或配置文件提供:\n  %s", strings.Join(missing, "\n  "))
+	os.Exit(1)
 }
 
-func getYear(dateStr string) string {
-	if dateStr == "" {
-		return ""
-	}
-	t, err := time.Parse("2006-01-02", dateStr)
+// requireInput 读取必须由用户提供的输入，标准输入已结束时提示改用命令行参数并退出，
+// 避免以空值继续运行后因“无效的选项”等原因莫名退出
+func requireInput(prompt string) string {
+	input, err := readLine(prompt)
 	if err != nil {
-		return ""
+		fmt.Println()
+		errorf("没有可读取的输入（标准输入已结束），非交互运行时请通过命令行参数提供，如 -dir、-id tv:1396，API密钥可通过 CR_TMDB_API_KEY 环境变量设置")
+		os.Exit(1)
 	}
-	return fmt.Sprintf("%d", t.Year())
+	return input
 }
 
-func ensureTwoDigits(num string) string {
-	if len(num) == 1 {
-		return "0" + num
+// isInteractive 标准输入是否为终端，输入来自管道或文件时跳过可有可无的确认
+func isInteractive() bool {
+	if noPrompt {
+		return false
 	}
-	return num
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
 }
 
-func parseFileName(fileName string) FileInfo {
-	info := FileInfo{}
+func getIntInput(prompt string) (int, error) {
+	input := requireInput(prompt)
+	return strconv.Atoi(input)
+}
 
-	formatRegex := regexp.MustCompile(`\b(1080[pP]|720[pP]|2160[pP]|4[kK]|8[kK]|480[pP]|HDR|HEVC|H265)\b`)
-	if matches := formatRegex.FindAllString(fileName, -1); len(matches) > 0 {
-		formats := make([]string, 0)
-		for _, match := range matches {
-			format := strings.ToUpper(match)
-			if format == "HEVC" || format == "H265" {
-				continue // 跳过编码格式
-			}
-			formats = append(formats, format)
-		}
-		info.VideoFormat = strings.Join(formats, ".")
+// request 在ctx下执行一次TMDB请求，timeout大于0时限制单次请求的耗时；
+// 仅在请求期间拦截Ctrl+C，用于中断请求，其余时间（如等待输入时）仍按默认方式直接退出
+func request(ctx context.Context, timeout time.Duration, do func(ctx context.Context) error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
+	return do(ctx)
+}
 
-	seasonEpisodePatterns := []string{
-		`[Ss](\d{1,2})[Ee](\d{1,2})`,
-		`第(\d{1,2})季.?第(\d{1,2})集`,
-		`Season\s*(\d{1,2}).*?Episode\s*(\d{1,2})`,
+// parseMediaID 解析 tv:1396、movie:603 形式的媒体类型和TMDB ID
+func parseMediaID(token string) (string, int, error) {
+	mediaType, idStr, ok := strings.Cut(token, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("格式应为 类型:ID，如 tv:1396")
 	}
-
-	episodeOnlyPatterns := []string{
-		`[Ee](\d{1,2})[^0-9]`,
-		`第(\d{1,2})集`,
-		`[Ee]p\.?(\d{1,2})`,
-		`[Ee]pisode\.?(\d{1,2})`,
-		`EP(\d{1,2})`,
-		`Ep(\d{1,2})`,
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType != tmdb.MediaTypeMovie && mediaType != tmdb.MediaTypeTV {
+		return "", 0, fmt.Errorf("无效的媒体类型 %s，可选: movie、tv", mediaType)
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("无效的TMDB ID %s", idStr)
 	}
+	return mediaType, id, nil
+}
 
-	foundMatch := false
-	for _, pattern := range seasonEpisodePatterns {
-		re := regexp.MustCompile(pattern)
-		if matches := re.FindStringSubmatch(fileName); len(matches) == 3 {
-			info.Season = ensureTwoDigits(matches[1])
-			info.Episode = ensureTwoDigits(matches[2])
-			info.FullMatch = matches[0]
-			foundMatch = true
-			break
-		}
+// parseTitleRegex 编译 -title-regex-group 指定的正则，要求其中有名为 title 的分组
+func parseTitleRegex(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if re.SubexpIndex("title") < 0 {
+		return nil, fmt.Errorf("缺少名为 title 的分组，如 ^(?P<title>.+?)\\.S\\d+E\\d+")
 	}
+	return re, nil
+}
 
-	if !foundMatch {
-		for _, pattern := range episodeOnlyPatterns {
-			re := regexp.MustCompile(pattern)
-			if matches := re.FindStringSubmatch(fileName); len(matches) == 2 {
-				info.Season = "01" // 默认为第一季
-				info.Episode = ensureTwoDigits(matches[1])
-				info.FullMatch = matches[0]
-				break
+// regexTitle 依次对文件名应用re，返回第一个提取出的非空title分组及其所在的文件名，都提取不到时返回空字符串
+func regexTitle(re *regexp.Regexp, files []string) (title, fileName string) {
+	group := re.SubexpIndex("title")
+	for _, file := range files {
+		name := filepath.Base(file)
+		if m := re.FindStringSubmatch(name); m != nil {
+			if title := strings.TrimSpace(m[group]); title != "" {
+				return title, name
 			}
 		}
 	}
+	return "", ""
+}
 
-	return info
+// filenameTitle 返回fixedTitle在文件名中的原始写法（保留文件名中的大小写），找不到时返回空字符串
+func filenameTitle(fileName, fixedTitle string) string {
+	if fixedTitle == "" {
+		return ""
+	}
+	idx := strings.Index(strings.ToLower(fileName), strings.ToLower(fixedTitle))
+	if idx == -1 {
+		return ""
+	}
+	return fileName[idx : idx+len(fixedTitle)]
 }
 
-func findMatchingFiles(dir, pattern string) ([]string, error) {
-	var files []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+// findExtraMatches 在多个目录中查找被matchPattern命中、但不在files中的文件，levels的含义同 recognition.MatchOptions.Levels
+func findExtraMatches(dirs []string, matchPattern string, files []string, levels int) ([]string, error) {
+	var extra []string
+	for _, dir := range dirs {
+		found, err := recognition.FindExtraMatches(dir, matchPattern, files, levels)
 		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			matched, err := regexp.MatchString(pattern, info.Name())
-			if err != nil {
-				return err
-			}
-			if matched {
-				files = append(files, path)
-			}
+			return nil, err
 		}
-		return nil
-	})
-	return files, err
+		extra = append(extra, found...)
+	}
+	return dedupe(extra), nil
 }
 
-func findCommonPattern(files []string, fixedTitle string) (string, string, string) {
-	if len(files) == 0 {
-		return "", "", ""
-	}
+// sizeUnits 文件大小单位，按1024计算
+var sizeUnits = map[string]float64{
+	"":   1,
+	"B":  1,
+	"K":  1 << 10,
+	"KB": 1 << 10,
+	"M":  1 << 20,
+	"MB": 1 << 20,
+	"G":  1 << 30,
+	"GB": 1 << 30,
+	"T":  1 << 40,
+	"TB": 1 << 40,
+}
 
-	// 获取第一个文件的信息作为基准
-	firstFile := filepath.Base(files[0])
-	fileInfo := parseFileName(firstFile)
-	videoFormat := fileInfo.VideoFormat
+var sizeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([A-Za-z]*)$`)
 
-	// 查找固定标题在文件名中的位置
-	idx := strings.Index(strings.ToLower(firstFile), strings.ToLower(fixedTitle))
-	if idx == -1 {
-		return "", "", ""
+// parseSize 解析 50MB、1.5GB、1024 这类文件大小，返回字节数，空字符串返回0
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
 	}
+	m := sizeRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("无法解析大小 %q，示例: 50MB", s)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(m[2])]
+	if !ok {
+		return 0, fmt.Errorf("未知的大小单位 %q，可选: B、KB、MB、GB、TB", m[2])
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	return int64(n * unit), nil
+}
 
-	// 分割前缀和后缀
-	prefix := firstFile[:idx]
-	suffix := firstFile[idx+len(fixedTitle):]
-
-	// 从后缀中提取季集信息之前的部分
-	seasonEpPattern := regexp.MustCompile(`S\d+E\d+`)
-	if loc := seasonEpPattern.FindStringIndex(suffix); loc != nil {
-		suffix = suffix[loc[0]:]
+// parseEpisodeRange 解析 5-12 这类集数范围（含两端），单个数字表示只有这一集，空字符串返回0, 0
+func parseEpisodeRange(s string) (from, to int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	first, last, found := strings.Cut(s, "-")
+	if !found {
+		last = first
+	}
+	from, err1 := strconv.Atoi(strings.TrimSpace(first))
+	to, err2 := strconv.Atoi(strings.TrimSpace(last))
+	if err1 != nil || err2 != nil || from < 0 || to < from {
+		return 0, 0, fmt.Errorf("无法解析集数范围 %q，示例: 5-12", s)
 	}
+	return from, to, nil
+}
 
-	// 从后缀中提取视频格式之后的部分
-	if videoFormat != "" {
-		if idx := strings.Index(strings.ToUpper(suffix), videoFormat); idx != -1 {
-			suffix = suffix[:idx] + videoFormat + ".*"
+// parseSeasonList 解析 2,4 这类以逗号分隔的季数列表，去掉重复的季数，空字符串返回nil
+func parseSeasonList(s string) ([]int, error) {
+	var seasons []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("无法解析季数 %q，示例: 2,4", part)
+		}
+		if !seen[n] {
+			seen[n] = true
+			seasons = append(seasons, n)
 		}
 	}
+	return seasons, nil
+}
 
-	// 将前缀和后缀中的特殊字符转换为正则表达式
-	prefix = regexp.QuoteMeta(prefix)
-	suffix = regexp.QuoteMeta(suffix)
+// dedupe 去掉重复的路径（目录相互包含时可能出现），保持原有顺序
+func dedupe(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	result := paths[:0]
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	return result
+}
 
-	// 替换掉后缀中视频格式后的具体字符
-	suffix = strings.Replace(suffix, `\.*`, `.*`, 1)
+// newClient 按配置（语言、API地址）创建TMDB客户端
+func newClient(config *Config, apiKey string) *tmdb.Client {
+	client := tmdb.NewClient(apiKey)
+	if config.Language != "" {
+		client.Language = config.Language
+	}
+	if config.BaseURL != "" {
+		client.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	}
+	return client
+}
 
-	// 替换季集信息为通配符
-	suffix = seasonEpPattern.ReplaceAllString(suffix, `S(\d{1,2})E(\d{1,2})`)
+// providerRegion 返回 -providers 查询观看渠道的地区：配置中的 provider_region，未设置时取客户端语言中的地区（zh-CN 为 CN），都没有时为 US
+func providerRegion(config *Config, client *tmdb.Client) string {
+	if config.ProviderRegion != "" {
+		return strings.ToUpper(config.ProviderRegion)
+	}
+	if _, region, ok := strings.Cut(client.Language, "-"); ok && region != "" {
+		return strings.ToUpper(region)
+	}
+	return "US"
+}
 
-	return prefix, suffix, videoFormat
+// usage 在默认的参数说明之后列出所有命名预设
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "用法: %s [参数] [目录或文件...]\n\n", filepath.Base(os.Args[0]))
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\n命名预设（-preset）:")
+	for _, name := range recognition.PresetNames() {
+		p := recognition.Presets[name]
+		fmt.Fprintf(out, "  %-10s %s\n", name, p.Description)
+		fmt.Fprintf(out, "  %-10s 电影: %s；电视剧: %s\n", "", p.MovieTemplate, p.TVTemplate)
+	}
 }
 
-func generateRegexPattern(files []string, fixedTitle string) (string, string, string) {
-	if len(files) == 0 {
-		return "", "", ""
+// printParseResults 以表格形式输出每个文件的识别结果，未识别的字段显示为 -
+func printParseResults(files []string, opts recognition.ParseOptions) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "文件\t季\t集\t置信度\t单集标题\t格式\t色深\t编码\t片源\t音频\t发布组\t卷\t标记\t分段")
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	for _, file := range files {
+		info, confidence := recognition.MatchPath(file, opts)
+		season := info.Season
+		if info.DefaultSeason {
+			season += "（默认）"
+		}
+		fields := []string{filepath.Base(file), season, info.Episode, strconv.FormatFloat(confidence, 'f', 2, 64), info.EpisodeTitle, info.VideoFormat, info.BitDepth,
+			info.Codec, info.Source, info.Audio, info.Group, info.Volume, info.Flags, info.Part}
+		for i := range fields {
+			fields[i] = orDash(fields[i])
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
 	}
+	w.Flush()
+}
 
-	// 获取第一个文件的基本信息
-	firstFile := filepath.Base(files[0])
-	fileInfo := parseFileName(firstFile)
-	videoFormat := fileInfo.VideoFormat
+// printPatterns 按优先级输出所有识别规则
+func printPatterns() {
+	for _, group := range recognition.PatternGroups() {
+		fmt.Printf("[%s]\n", group.Name)
+		for i, pattern := range group.Patterns {
+			fmt.Printf("  %d. %s\n", i+1, pattern)
+		}
+	}
+}
 
-	// 查找固定标题在文件名中的位置
-	idx := strings.Index(strings.ToLower(firstFile), strings.ToLower(fixedTitle))
-	if idx == -1 {
-		return "", "", ""
+// printVersion 输出版本、提交和构建时间，未注入时尝试从Go构建信息中读取
+func printVersion() {
+	rev, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && rev == "unknown":
+				rev = setting.Value
+			case setting.Key == "vcs.time" && date == "unknown":
+				date = setting.Value
+			}
+		}
 	}
+	fmt.Printf("custom-recognition %s (commit: %s, built: %s, %s)\n", version, rev, date, runtime.Version())
+}
 
-	// 分析所有文件名，找出共同模式
-	commonPrefix := firstFile[:idx]
+func main() {
+	episodeGroupID := flag.String("episode-group", "", "TMDB剧集组ID，按替代排序（绝对顺序、DVD等）映射集数，不指定则使用默认排序")
+	showVersion := flag.Bool("version", false, "显示版本信息并退出")
+	listPatterns := flag.Bool("list-patterns", false, "按优先级列出所有识别用的正则表达式并退出")
+	presetFlag := flag.String("preset", "", "媒体服务器命名预设: "+strings.Join(recognition.PresetNames(), ", ")+"，可被 -template/-tag-format 覆盖")
+	tagFormat := flag.String("tag-format", "", "{tag}的格式，可使用{tmdbid}和{type}，如 [tmdbid-{tmdbid}]")
+	keepBetween := flag.Bool("keep-between", false, "保留标题与季集之间的文本（如系列副标题），通过{between}输出，模板中未包含时紧跟在{title}之后")
+	tagType := flag.String("tag-type", "", "输出标签中的媒体类型（movie或tv），与查询TMDB使用的类型分开指定")
+	templateFlag := flag.String("template", "", "文件名模板，可用占位符: {title} {year} {yearRange} {season} {episode} {between} {format} {bitdepth} {codec} {source} {volume} {flags} {part} {status} {provider} {tmdbid} {type} {tag}")
+	titleFlag := flag.String("title", "", "输出文件名使用的标题，不指定时使用TMDB标题")
+	keepFilenameTitle := flag.Bool("keep-filename-title", false, "使用文件名中标题固定部分的原始写法作为{title}，ID和年份仍取自TMDB")
+	wholeName := flag.Bool("whole-name", false, "单文件规则以去掉扩展名的完整文件名（原样转义并以 ^$ 锚定）作为被替换词，替换为完整的新文件名，适用于单部电影等一次性的重命名")
+	perFile := flag.Bool("per-file", false, "为每个匹配的文件分别输出以完整文件名为被替换词的单文件规则")
+	seasonPad := flag.Int("season-pad", 2, "输出中季数补零后的位数，0或1表示不补零（S1）")
+	episodePad := flag.Int("episode-pad", 2, "输出中集数补零后的位数，如 3 输出 E001")
+	majorityFormat := flag.Bool("majority-format", false, "第一个文件的视频格式与多数文件不同时，规则中改用多数文件的格式")
+	percentDecode := flag.Bool("percent-decode", false, "解析文件名前先解码 %20、%5B 等URL编码")
+	providers := flag.Bool("providers", false, "同时查询TMDB的观看渠道（watch/providers），以配置地区中订阅即可观看的流媒体服务作为{provider}，没有时为空")
+	yearSource := flag.String("year-source", tmdb.YearSourceRelease, "电影年份取自: release（详情中的 release_date）、digital（各地区最早的数字发行日期）、earliest（各地区、各类型中最早的上映日期），后两种没有数据时使用 release_date；电视剧始终使用首播日期")
+	preferOriginal := flag.Bool("prefer-original", false, "使用TMDB原始标题（original_title/original_name）代替中文标题")
+	dirFlag := flag.String("dir", "", "视频文件所在目录，也可以是单个文件的路径；更多目录可作为位置参数追加在参数之后")
+	printURL := flag.Bool("print-url", false, "输出每次TMDB请求的URL（隐藏API密钥）、状态码和耗时")
+	jsonOut := flag.String("json", "", "将结果（规则、未匹配文件、重命名计划、错误）以JSON格式写入该文件")
+	validate := flag.Bool("validate", false, "对照TMDB季详情校验集数，并标记尚未播出的剧集")
+	folderSeason := flag.Bool("folder-season", true, "文件名中没有季数时，从所在文件夹名称（Season 3、S03、第三季）读取季数")
+	dedupeFlag := flag.Bool("dedupe", false, "同一集有多个文件时只处理其中一个：优先 PROPER/REPACK（REPACK2 等版本号高的优先），其次分辨率高的，其余文件列出后跳过")
+	planOut := flag.String("plan", "", "只计算重命名计划（与 -apply 相同）并以JSON格式写入该文件，不执行重命名，检查或修改后可用 -apply-plan 执行")
+	applyPlanFlag := flag.String("apply-plan", "", "读取 -plan 写入的重命名计划，重新校验源文件仍然存在后执行重命名并退出，目标文件已存在时按 -on-conflict 处理，不请求TMDB")
+	apply := flag.Bool("apply", false, "按生成的文件名直接重命名匹配的文件（执行前需要确认）")
+	interactive := flag.Bool("interactive", true, "是否在缺少参数时提示输入，false 时所有值都需要通过命令行参数、环境变量或配置文件提供，缺少时列出后退出，也不再等待回车退出，适用于cron、CI等无人值守运行")
+	titleRegexFlag := flag.String("title-regex-group", "", "从文件名中自动提取标题固定部分的正则，需包含名为 title 的分组，如 ^(?P<title>.+?)\\.S\\d+E\\d+；提取不到时仍提示输入")
+	matchFlag := flag.String("match", "", "要匹配的标题固定部分，指定后跳过对应的输入；不指定时非交互模式下匹配所有视频文件")
+	yes := flag.Bool("yes", false, "跳过TMDB条目确认；配合 -apply 使用时同时跳过重命名确认")
+	onConflict := flag.String("on-conflict", conflictSkip, "目标文件已存在时的处理方式: skip（跳过）、overwrite（覆盖）、suffix（追加序号）")
+	dest := flag.String("dest", "", "配合 -apply 使用，将重命名后的文件移动到该目录（支持跨文件系统）")
+	printPaths := flag.Bool("print-paths", false, "配合 -apply 或 -apply-plan 使用，执行后输出每个文件重命名后所在位置的绝对路径（每行一个），-json 结果中的 final 字段同样为该路径")
+	sidecars := flag.Bool("sidecars", false, "配合 -apply 使用，同时重命名与视频同名的 .nfo 元数据文件")
+	idFlag := flag.String("id", "", "同时指定媒体类型和TMDB ID，如 tv:1396、movie:603，跳过对应的输入")
+	autoPickFirst := flag.Bool("auto-pick-first", false, "按名称搜索时直接选择按热度和年份排序后排在第一的结果，不再提示选择")
+	both := flag.Bool("both", false, "同时按电影和电视剧查询TMDB ID（跳过返回404的一种），列出两种结果后选择")
+	volumeAsSeason := flag.Bool("volume-as-season", false, "文件名中没有季数时，将卷号（Vol.2、Volume 2）作为季数")
+	configPath := flag.String("config", defaultConfigPath, "配置文件路径，默认为工作目录中的 "+defaultConfigPath)
+	doctor := flag.Bool("doctor", false, "检查配置文件、API密钥、网络连接和目录写入权限并退出")
+	dumpConfigFlag := flag.Bool("dump-config", false, "以JSON格式输出合并配置文件、环境变量和命令行参数后实际生效的配置（API密钥已隐藏）并退出")
+	writeNFO := flag.Bool("write-nfo", false, "为每集写入包含TMDB单集标题、播出日期、时长和简介的 .nfo 文件（需配合 -apply 并确认执行，否则仅预览）")
+	parseOnly := flag.Bool("parse-only", false, "只输出每个匹配文件的识别结果（季集、格式、片源、音频、发布组、多音轨/多字幕标记等）并退出，不请求TMDB也不重命名")
+	matcherFlag := flag.String("matcher", "regex", "识别季集的策略: "+strings.Join(recognition.MatcherNames(), "、")+"，anime 额外识别 [Group] Show - 05 [1080p] 这类动画命名")
+	backrefStyle := flag.String("backref-style", recognition.BackrefBackslash, "替换词中分组引用的写法: backslash（\\1）、dollar（${1}，用于使用 $ 替换语法的工具）")
+	onlySeasonsFlag := flag.String("only-seasons", "", "只处理识别出的季数在该列表中的文件，以逗号分隔，如 2,4，其余文件列出后跳过")
+	episodeOffset := flag.Int("episode-offset", 0, "从识别出的集数中减去该值，用于跨季连续编号的文件（如第二季为13-24集时指定12），在剧集组映射和 -episodes 筛选之前生效")
+	episodesFlag := flag.String("episodes", "", "只处理集数在该范围内（含两端）的文件，如 5-12，生成的批量规则也只匹配这些集数")
+	ignoreMarker := flag.Bool("ignore-marker", false, "不读取目录中记录上次所选TMDB条目的 "+markerFileName+" 文件，并用本次的选择覆盖")
+	noMarker := flag.Bool("no-marker", false, "不向目录中写入记录所选TMDB条目的 "+markerFileName+" 文件")
+	noDefaultSeason := flag.Bool("no-default-season", false, "电视剧中只有集数、文件夹中也没有季数的文件不默认为第一季，而是排除在规则和重命名之外并单独列出")
+	allSeasons := flag.Bool("all-seasons", false, "以剧集根目录处理整部剧：按季文件夹（Season 2、S02、第二季）分组，逐季输出批量规则，TMDB只查询一次")
+	exportRulesFlag := flag.String("export-rules", "", "将生成的规则以“被替换词<TAB>替换词”每行一条的格式写入该文件，供外部批量重命名工具导入，选取规则的方式与 -clipboard 相同")
+	clipboard := flag.Bool("clipboard", false, "将生成的规则（“被替换词 => 替换词”）复制到剪贴板，有逐个文件的规则时复制这些规则，否则优先复制批量规则")
+	partsMode := flag.String("parts", partsKeep, "电影分为 CD1/CD2、Disc 1/Disc 2 等多个文件时的处理方式: keep（在文件名末尾保留分段标记{part}）、flag（只列出并标记为同一部电影的多个部分，不修改模板）")
+	maxDepth := flag.Int("max-depth", -1, "限制在目录中向下查找的层数，0表示只查找指定的目录本身，1表示再加上其直接子目录，负数表示不限制")
+	minSize := flag.String("min-size", "", "忽略小于该大小的文件（如未下载完成的文件），如 50MB、1.5GB，单位按1024计算")
+	fuzzy := flag.Float64("fuzzy", 0, "标题模糊匹配阈值（0-1，标准化编辑距离），0表示仅精确匹配")
+	logLevelFlag := flag.String("log-level", "info", "日志级别: "+strings.Join(logLevelList(), "、")+"，info、debug输出到标准输出，warn、error输出到标准错误；debug还会输出每个文件的识别结果和每次TMDB请求")
+	timeout := flag.Duration("timeout", 30*time.Second, "单次TMDB请求的超时时间，0表示不限制")
+	flag.Usage = usage
+	flag.Parse()
+	noPrompt = !*interactive
+
+	level, ok := logLevelNames[strings.ToLower(*logLevelFlag)]
+	if !ok {
+		errorf("无效的日志级别: %s，可选: %s", *logLevelFlag, strings.Join(logLevelList(), "、"))
+		os.Exit(1)
+	}
+	currentLevel = level
 
-	// 提取第一个文件的季集信息位置
-	seasonEpPattern := regexp.MustCompile(`S\d+E\d+`)
-	firstFileSuffix := firstFile[idx+len(fixedTitle):]
-	seasonEpLoc := seasonEpPattern.FindStringIndex(firstFileSuffix)
-	if seasonEpLoc == nil {
-		return "", "", ""
+	if *showVersion {
+		printVersion()
+		return
 	}
 
-	// 分析所有文件，找出共同的前缀和后缀模式
-	for i, file := range files {
-		fileName := filepath.Base(file)
-		if i == 0 {
-			continue
+	fileConfig, err := readConfig(*configPath)
+	if *doctor {
+		if !runDoctor(context.Background(), *timeout, *configPath, fileConfig, err) {
+			os.Exit(1)
 		}
-
-		// 查找当前文件中的固定标题位置
-		currIdx := strings.Index(strings.ToLower(fileName), strings.ToLower(fixedTitle))
-		if currIdx == -1 {
-			continue
+		return
+	}
+	// 配置文件路径是目录时不作为致命错误，本次运行仅使用环境变量和命令行参数
+	if errors.Is(err, errConfigIsDir) {
+		warnf("%v；本次运行仅使用环境变量和命令行参数", err)
+		err = nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		errorf("读取配置文件失败: %v", err)
+		os.Exit(1)
+	}
+	// 环境变量优先于配置文件，保存时只写回配置文件中原有的内容
+	config := withEnv(fileConfig)
+	for _, err := range recognition.AddPatterns(config.ExtraSeasonEpisodePatterns, config.ExtraEpisodeOnlyPatterns) {
+		warnf("配置文件中的自定义识别规则无效，已忽略：%v", err)
+	}
+	if *dumpConfigFlag {
+		effective := *config
+		if *templateFlag != "" {
+			effective.Template = *templateFlag
+		}
+		client := newClient(config, "")
+		effective.Language, effective.BaseURL = client.Language, client.BaseURL
+		if err := dumpConfig(os.Stdout, effective); err != nil {
+			errorf("输出配置失败: %v", err)
+			os.Exit(1)
 		}
+		return
+	}
+	matcher, ok := recognition.LookupMatcher(*matcherFlag)
+	if !ok {
+		errorf("未知的识别策略: %s，可选: %s", *matcherFlag, strings.Join(recognition.MatcherNames(), "、"))
+		os.Exit(1)
+	}
+	parseOpts := recognition.ParseOptions{
+		Matcher:             matcher,
+		FolderSeason:        *folderSeason,
+		KeepResolutionLabel: config.KeepResolutionLabel,
+		VolumeAsSeason:      *volumeAsSeason,
+		PercentDecode:       *percentDecode,
+		EpisodeOffset:       *episodeOffset,
+	}
 
-		// 更新共同前缀
-		currPrefix := fileName[:currIdx]
-		commonPrefix = findCommonPrefixPattern(commonPrefix, currPrefix)
+	if *listPatterns {
+		printPatterns()
+		return
 	}
 
-	// 构建最终的模式
-	prefix := regexp.QuoteMeta(commonPrefix)
-	suffix := `S(\d{1,2})E(\d{1,2}).*` + regexp.QuoteMeta(videoFormat)
+	if *printURL || currentLevel >= levelDebug {
+		tmdb.Trace = os.Stderr
+	}
+	recognition.Warnings = levelWriter(levelWarn)
 
-	// 替换数字序列为通配符
-	prefix = regexp.MustCompile(`\d+`).ReplaceAllString(prefix, `\d+`)
+	switch *tagType {
+	case "", tmdb.MediaTypeMovie, tmdb.MediaTypeTV:
+	default:
+		errorf("无效的标签类型: %s，可选: movie、tv", *tagType)
+		os.Exit(1)
+	}
 
-	return prefix, suffix, videoFormat
-}
+	switch *onConflict {
+	case conflictSkip, conflictOverwrite, conflictSuffix:
+	default:
+		errorf("无效的冲突处理方式: %s，可选: skip、overwrite、suffix", *onConflict)
+		os.Exit(1)
+	}
 
-func findCommonPrefixPattern(a, b string) string {
-	// 如果前缀完全相同，直接返回
-	if a == b {
-		return a
+	if *applyPlanFlag != "" {
+		if noPrompt && !*yes {
+			requireValues([]string{"重命名确认：-yes（非交互模式下执行重命名计划需要指定）"})
+		}
+		if !applyPlan(*applyPlanFlag, *yes, *onConflict, *printPaths) {
+			os.Exit(1)
+		}
+		return
 	}
 
-	// 将连续数字替换为单个占位符
-	numPattern := regexp.MustCompile(`\d+`)
-	a = numPattern.ReplaceAllString(a, "#")
-	b = numPattern.ReplaceAllString(b, "#")
+	ctx := context.Background()
 
-	// 查找共同的非数字部分
-	var result strings.Builder
-	for i := 0; i < len(a) && i < len(b); i++ {
-		if a[i] == b[i] {
-			result.WriteByte(a[i])
-		} else if a[i] == '#' && b[i] == '#' {
-			result.WriteByte('#')
-		} else {
-			break
+	var preset recognition.Preset
+	if *presetFlag != "" {
+		p, ok := recognition.Presets[strings.ToLower(*presetFlag)]
+		if !ok {
+			errorf("未知的预设: %s，可用预设: %s", *presetFlag, strings.Join(recognition.PresetNames(), ", "))
+			os.Exit(1)
 		}
+		preset = p
 	}
 
-	// 将占位符替换回数字通配符
-	return strings.ReplaceAll(result.String(), "#", `\d+`)
-}
-
-func showRegexRules(originalName, fixedTitle, title, year string, info FileInfo, mediaType string, tmdbID int) {
-	fmt.Println("\n=== 正则替换规则 ===")
-	fmt.Println("原始文件名:\n", originalName)
-	fmt.Println("\n要替换成:")
+	var mediaType string
+	var tmdbID int
+	if *idFlag != "" {
+		mediaType, tmdbID, err = parseMediaID(*idFlag)
+		if err != nil {
+			errorf("无效的 -id 参数: %v", err)
+			os.Exit(1)
+		}
+	}
 
-	videoFormat := strings.ToLower(info.VideoFormat)
+	if *seasonPad < 0 || *episodePad < 0 {
+		errorf("-season-pad 和 -episode-pad 不能为负数，程序退出")
+		os.Exit(1)
+	}
 
-	if mediaType == MediaTypeMovie {
-		finalName := fmt.Sprintf("%s.%s.%s.{[tmdbid=%d;type=movie]}",
-			title, year, videoFormat, tmdbID)
-		fmt.Println(finalName)
+	minSizeBytes, err := parseSize(*minSize)
+	if err != nil {
+		errorf("无效的 -min-size 参数: %v", err)
+		os.Exit(1)
+	}
 
-		pattern := regexp.QuoteMeta(originalName)
+	// 目录层数，0表示不限制
+	levels := 0
+	if *maxDepth >= 0 {
+		levels = *maxDepth + 1
+	}
 
-		fmt.Println()
-		fmt.Printf("被替换词: \n%s\n", pattern)
-		fmt.Printf("替换词: \n%s.%s.%s.{[tmdbid=%d;type=movie]}\n",
-			title, year, videoFormat, tmdbID)
-	} else {
-		finalName := fmt.Sprintf("%s.%s.S%sE%s.%s.{[tmdbid=%d;type=tv]}",
-			title, year, info.Season, info.Episode, videoFormat, tmdbID)
-		fmt.Println(finalName)
+	episodeFrom, episodeTo, err := parseEpisodeRange(*episodesFlag)
+	if err != nil {
+		errorf("无效的 -episodes 参数: %v", err)
+		os.Exit(1)
+	}
 
-		// 构建正则表达式模式
-		pattern := fmt.Sprintf("%s\\.?.*?[Ss](\\d{1,2})[Ee](\\d{1,2})\\.?.*?[0-9]+[pPkK]\\.?.*",
-			regexp.QuoteMeta(fixedTitle))
+	onlySeasons, err := parseSeasonList(*onlySeasonsFlag)
+	if err != nil {
+		errorf("无效的 -only-seasons 参数: %v", err)
+		os.Exit(1)
+	}
 
-		fmt.Println()
-		fmt.Printf("被替换词: \n%s\n", pattern)
-		fmt.Printf("替换词: \n%s.%s.S\\1E\\2.%s.{[tmdbid=%d;type=tv]}\n",
-			title, year, videoFormat, tmdbID)
+	switch *partsMode {
+	case partsKeep, partsFlag:
+	default:
+		errorf("无效的分段处理方式: %s，可选: %s、%s", *partsMode, partsKeep, partsFlag)
+		os.Exit(1)
 	}
-}
 
-func showBatchRegexRules(prefix, suffix, fixedTitle, title, year, videoFormat string, tmdbID int) {
-	fmt.Println("\n=== 批量正则替换规则 ===")
+	switch *yearSource {
+	case tmdb.YearSourceRelease, tmdb.YearSourceDigital, tmdb.YearSourceEarliest:
+	default:
+		errorf("无效的年份来源: %s，可选: %s、%s、%s", *yearSource, tmdb.YearSourceRelease, tmdb.YearSourceDigital, tmdb.YearSourceEarliest)
+		os.Exit(1)
+	}
 
-	// 构建匹配模式
-	matchPattern := fmt.Sprintf("%s\\.?.*?[Ss](\\d{1,2})[Ee](\\d{1,2})\\.?.*?[0-9]+[pPkK]\\.?.*",
-		regexp.QuoteMeta(fixedTitle))
+	switch *backrefStyle {
+	case recognition.BackrefBackslash, recognition.BackrefDollar:
+	default:
+		errorf("无效的分组引用写法: %s，可选: %s、%s", *backrefStyle, recognition.BackrefBackslash, recognition.BackrefDollar)
+		os.Exit(1)
+	}
 
-	fmt.Printf("匹配模式: \n%s\n\n", matchPattern)
+	var titleRegex *regexp.Regexp
+	if *titleRegexFlag != "" {
+		if titleRegex, err = parseTitleRegex(*titleRegexFlag); err != nil {
+			errorf("无效的 -title-regex-group 参数: %v", err)
+			os.Exit(1)
+		}
+	}
 
-	// 构建替换模式
-	replacePattern := fmt.Sprintf("%s.%s.S\\1E\\2.%s.{[tmdbid=%d;type=tv]}",
-		title, year, videoFormat, tmdbID)
-	fmt.Printf("替换为: \n%s\n", replacePattern)
+	if *fuzzy < 0 || *fuzzy > 1 {
+		errorf("模糊匹配阈值必须在0到1之间，程序退出")
+		os.Exit(1)
+	}
 
-	fmt.Println("\n使用说明:")
-	fmt.Println("1. 使用上述正则表达式可以匹配目录下所有相关剧集文件")
-	fmt.Println("2. \\1 表示季数，\\2 表示集数")
-	fmt.Println("3. 视频格式会保持文件原有的格式")
-}
+	// 获取视频所在的目录，-dir 和位置参数可以同时指定多个，都没有时提示输入
+	paths := flag.Args()
+	if *dirFlag != "" {
+		paths = append([]string{*dirFlag}, paths...)
+	}
+	if len(paths) == 0 && !noPrompt {
+		dir := getInput("请输入视频文件所在目录或文件路径（直接回车表示当前目录）: ")
+		if dir == "" {
+			dir = "."
+		}
+		paths = []string{dir}
+	}
 
-func readConfig() (*Config, error) {
-	configPath := "custom-recognition.config"
-	file, err := os.Open(configPath)
-	if err != nil {
-		return nil, err
+	stats := make([]os.FileInfo, len(paths))
+	for i, path := range paths {
+		if stats[i], err = os.Stat(path); err != nil {
+			errorf("无法访问路径 %s: %v", path, err)
+			os.Exit(1)
+		}
 	}
-	defer file.Close()
 
-	var config Config
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&config)
-	if err != nil {
-		return nil, err
+	// 在每个目录中查找匹配的文件并合并，指定的是单个文件时直接处理该文件
+	var dirs, singles []string
+	for i, path := range paths {
+		if stats[i].Mode().IsRegular() {
+			singles = append(singles, path)
+		} else {
+			dirs = append(dirs, path)
+		}
 	}
 
-	return &config, nil
-}
+	// 非交互模式下在开始处理前一次性检查所有必需的值，不会在中途等待输入
+	if noPrompt {
+		var missing []string
+		if len(paths) == 0 {
+			missing = append(missing, "视频文件所在目录：-dir 或在参数之后指定目录、文件")
+		}
+		if !*parseOnly {
+			if config.TMDBApiKey == "" {
+				missing = append(missing, "TMDB API密钥：CR_TMDB_API_KEY 环境变量或配置文件中的 tmdb_api_key")
+			}
+			if *both {
+				missing = append(missing, "媒体类型：-both 两种结果都存在时需要手动选择，请改用 -id 指定类型和ID")
+			} else if m, _ := reusableMarker(dirs, tmdbID, *both, *ignoreMarker); tmdbID == 0 && m == nil {
+				missing = append(missing, "媒体类型和TMDB ID：-id，如 tv:1396、movie:603（目录中有 "+markerFileName+" 记录时自动复用）")
+			}
+			if *apply && !*yes {
+				missing = append(missing, "重命名确认：-yes（非交互模式下配合 -apply 执行重命名需要指定）")
+			}
+		}
+		requireValues(missing)
+	}
+	findFiles := func(fixedTitle string) (files, tooSmall, discs []string) {
+		pattern := fmt.Sprintf(".*%s.*", regexp.QuoteMeta(fixedTitle))
+		if fixedTitle == "" {
+			pattern = recognition.VideoFilePattern
+		}
+		files = append(files, singles...)
+		for _, dir := range dirs {
+			found, small, err := recognition.FindMatchingFiles(dir, pattern, recognition.MatchOptions{
+				FixedTitle: fixedTitle,
+				Fuzzy:      *fuzzy,
+				MinSize:    minSizeBytes,
+				Levels:     levels,
+			})
+			if err != nil {
+				errorf("搜索文件失败: %v", err)
+				os.Exit(1)
+			}
+			// 原盘中的 00000.m2ts 等文件没有标题信息，改为整体处理原盘目录
+			for _, file := range found {
+				if _, ok := recognition.DiscRoot(file); !ok {
+					files = append(files, file)
+				}
+			}
+			tooSmall = append(tooSmall, small...)
+			found, err = recognition.FindDiscs(dir, fixedTitle, levels)
+			if err != nil {
+				errorf("搜索原盘目录失败: %v", err)
+				os.Exit(1)
+			}
+			discs = append(discs, found...)
+		}
+		return dedupe(files), dedupe(tooSmall), dedupe(discs)
+	}
 
-func saveConfig(config *Config) error {
-	configPath := "custom-recognition.config"
-	file, err := os.Create(configPath)
-	if err != nil {
-		return err
+	// 获取要匹配的标题部分，留空时匹配目录下所有视频文件，仅依靠集数识别；
+	// 没有匹配的文件时列出部分文件名，提示重新输入，输入 * 匹配所有视频文件，输入 q 退出
+	fixedTitle := *matchFlag
+	if fixedTitle == "" && titleRegex != nil {
+		all, _, discs := findFiles("")
+		if title, name := regexTitle(titleRegex, append(all, discs...)); title != "" {
+			infof("从 %s 中提取出标题固定部分: %s", name, title)
+			fixedTitle = title
+		} else {
+			warnf("-title-regex-group 无法从任何文件名中提取出标题")
+		}
+	}
+	if fixedTitle == "" {
+		fixedTitle = getInput("请输入要匹配的标题固定部分（直接回车匹配所有视频文件）: ")
+	}
+	var files, tooSmall, discs []string
+	for {
+		files, tooSmall, discs = findFiles(fixedTitle)
+		if len(files) > 0 || len(discs) > 0 {
+			break
+		}
+		if noPrompt {
+			errorf("未找到包含 %q 的文件，程序退出", fixedTitle)
+			os.Exit(1)
+		}
+		samples, _, _ := findFiles("")
+		if len(samples) == 0 {
+			errorf("未找到匹配的文件，程序退出")
+			os.Exit(1)
+		}
+		fmt.Printf("未找到包含 %q 的文件，目录中的部分文件:\n", fixedTitle)
+		for _, file := range samples[:min(len(samples), 5)] {
+			fmt.Println("  " + filepath.Base(file))
+		}
+		// 重新输入时匹配所有视频文件需要明确输入 *，避免误按回车或标准输入结束时处理整个目录
+		input, err := readLine("请重新输入标题固定部分（输入 * 匹配所有视频文件，输入 q 退出）: ")
+		if err != nil {
+			fmt.Println()
+			errorf("没有可读取的输入（标准输入已结束），程序退出")
+			os.Exit(1)
+		}
+		switch {
+		case strings.EqualFold(input, "q"):
+			infof("程序退出")
+			os.Exit(0)
+		case input == "*":
+			fixedTitle = ""
+		case input != "":
+			fixedTitle = input
+		}
+	}
+	if len(tooSmall) > 0 {
+		infof("已跳过%d个小于 %s 的文件（可能未下载完成）:\n  %s", len(tooSmall), *minSize, strings.Join(tooSmall, "\n  "))
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "    ")
-	return encoder.Encode(config)
-}
+	// 原盘（BDMV/VIDEO_TS）以其根目录名称作为标题来源，整个原盘作为一部电影处理；
+	// 与普通视频文件混在一起时只处理普通文件
+	if len(discs) > 0 {
+		if len(files) > 0 {
+			infof("已跳过%d个原盘目录（BDMV/VIDEO_TS），请单独指定这些目录:\n  %s", len(discs), strings.Join(discs, "\n  "))
+		} else {
+			infof("检测到%d个原盘目录（BDMV/VIDEO_TS），以目录名作为标题来源，整个原盘按电影处理:\n  %s", len(discs), strings.Join(discs, "\n  "))
+			files = discs
+			if mediaType == "" && !*both {
+				mediaType = tmdb.MediaTypeMovie
+			}
+		}
+	}
 
-func main() {
-	// 获取当前目录
-	dir := getInput("请输入视频文件所在目录（直接回车表示当前目录）: ")
-	if dir == "" {
-		dir = "."
+	parseOpts.Title = fixedTitle
+	for _, file := range files {
+		info := recognition.ParsePath(file, parseOpts)
+		if info.Warning != "" {
+			warnf("%s %s", filepath.Base(file), info.Warning)
+		}
+		debugf("%s: 季=%s 集=%s 格式=%s 匹配=%q", filepath.Base(file), info.Season, info.Episode, info.VideoFormat, info.FullMatch)
 	}
 
-	// 获取要匹配的标题部分
-	fixedTitle := getInput("请输入要匹配的标题固定部分: ")
-	if fixedTitle == "" {
-		fmt.Println("标题不能为空，程序退出")
-		os.Exit(1)
+	// 只保留季数在 -only-seasons 中的文件，其余的列出后跳过
+	if len(onlySeasons) > 0 {
+		var kept, skipped []string
+		for _, file := range files {
+			season, err := strconv.Atoi(recognition.ParsePath(file, parseOpts).Season)
+			if err == nil && slices.Contains(onlySeasons, season) {
+				kept = append(kept, file)
+			} else {
+				skipped = append(skipped, file)
+			}
+		}
+		if len(skipped) > 0 {
+			infof("已跳过%d个不在 -only-seasons %s 中的文件:\n  %s", len(skipped), *onlySeasonsFlag, strings.Join(skipped, "\n  "))
+		}
+		if len(kept) == 0 {
+			errorf("没有季数在 -only-seasons 中的文件，程序退出")
+			os.Exit(1)
+		}
+		files = kept
 	}
 
-	// 查找匹配的文件
-	pattern := fmt.Sprintf(".*%s.*", regexp.QuoteMeta(fixedTitle))
-	files, err := findMatchingFiles(dir, pattern)
-	if err != nil {
-		fmt.Printf("搜索文件失败: %v\n", err)
-		os.Exit(1)
+	// 只保留集数在 -episodes 范围内的文件
+	if *episodesFlag != "" {
+		inRange := files[:0:0]
+		for _, file := range files {
+			episode, err := strconv.Atoi(recognition.ParsePath(file, parseOpts).Episode)
+			if err == nil && episode >= episodeFrom && episode <= episodeTo {
+				inRange = append(inRange, file)
+			}
+		}
+		infof("集数范围 %d-%d：%d个文件中有%d个在范围内", episodeFrom, episodeTo, len(files), len(inRange))
+		if len(inRange) == 0 {
+			errorf("没有集数在范围内的文件，程序退出")
+			os.Exit(1)
+		}
+		files = inRange
 	}
 
-	if len(files) == 0 {
-		fmt.Println("未找到匹配的文件，程序退出")
-		os.Exit(1)
+	if *parseOnly {
+		printParseResults(files, parseOpts)
+		return
 	}
 
-	fmt.Println("\n请选择要查询的媒体类型：")
-	fmt.Println("1. 电影")
-	fmt.Println("2. 电视节目")
-	choice := getInput("请输入选项（1或2）: ")
+	// 同一集有多个文件时只保留 PROPER/REPACK 或分辨率更高的一个，其余的列出后跳过
+	var duplicates []string
+	if *dedupeFlag {
+		files, duplicates = recognition.Dedupe(files, parseOpts)
+		if len(duplicates) > 0 {
+			fmt.Printf("\n=== 重复的剧集（%d个，已跳过） ===\n", len(duplicates))
+			for _, file := range duplicates {
+				fmt.Println(file)
+			}
+		}
+	}
 
-	var mediaType string
-	switch choice {
-	case "1":
-		mediaType = MediaTypeMovie
-	case "2":
-		mediaType = MediaTypeTV
-	default:
-		fmt.Println("无效的选项，程序退出")
-		os.Exit(1)
+	// 同一集的分卷文件（.mkv.001、.mkv.002）作为一个文件处理：逐个文件的规则只针对第一个分卷，重命名时各分卷保留各自的后缀
+	if groups := recognition.SplitGroups(files); len(groups) > 0 {
+		fmt.Printf("\n=== 分卷文件（%d组） ===\n", len(groups))
+		for _, group := range groups {
+			fmt.Printf("%s: %d个分卷\n", filepath.Base(recognition.SplitBase(group[0])), len(group))
+		}
+		warnf("分卷文件需要合并后才能播放，建议先合并再处理；重命名时各分卷保留 .001、.part2 等后缀")
 	}
 
-	tmdbID, err := getIntInput("请输入TMDB ID: ")
-	if err != nil || tmdbID <= 0 {
-		fmt.Println("无效的TMDB ID，程序退出")
-		os.Exit(1)
+	if *fuzzy > 0 && fixedTitle != "" {
+		for _, file := range files {
+			name := filepath.Base(file)
+			if !strings.Contains(strings.ToLower(name), strings.ToLower(fixedTitle)) {
+				infof("模糊匹配: %s", name)
+			}
+		}
 	}
 
-	var apiKey string
-	config, err := readConfig()
-	if err == nil && config.TMDBApiKey != "" {
-		apiKey = config.TMDBApiKey
-	} else {
-		apiKey = getInput("请输入TMDB API密钥: ")
+	apiKey := config.TMDBApiKey
+	if apiKey == "" {
+		apiKey = requireInput("请输入TMDB API密钥: ")
 		if apiKey == "" {
-			fmt.Println("API密钥不能为空，程序退出")
+			errorf("API密钥不能为空，程序退出")
 			os.Exit(1)
 		}
 
-		config = &Config{TMDBApiKey: apiKey}
-		if err := saveConfig(config); err != nil {
-			fmt.Printf("警告：无法保存配置文件：%v\n", err)
+		if fileConfig == nil {
+			fileConfig = &Config{}
+		}
+		fileConfig.TMDBApiKey = apiKey
+		if err := saveConfig(*configPath, fileConfig); err != nil {
+			warnf("无法保存配置文件：%v；API密钥仅在本次运行中使用", err)
+		}
+	}
+
+	client := newClient(config, apiKey)
+
+	// 目录中有上次选择的记录时，询问是否复用，复用时跳过媒体类型和ID的输入
+	reusedMarker := false
+	if m, dir := reusableMarker(dirs, tmdbID, *both, *ignoreMarker); m != nil {
+		prompt := fmt.Sprintf("\n%s 中记录了上次选择的 %s:%d（%s），是否复用？(Y/n): ", dir, m.MediaType, m.TMDBID, m.Title)
+		if noPrompt {
+			infof("复用 %s 中记录的上次选择 %s:%d（%s）", dir, m.MediaType, m.TMDBID, m.Title)
+			mediaType, tmdbID = m.MediaType, m.TMDBID
+			reusedMarker = true
+		} else if answer := getInput(prompt); answer == "" || strings.EqualFold(answer, "y") {
+			mediaType, tmdbID = m.MediaType, m.TMDBID
+			reusedMarker = true
+		}
+	}
+
+	if mediaType == "" && !*both {
+		fmt.Println("\n请选择要查询的媒体类型：")
+		fmt.Println("1. 电影")
+		fmt.Println("2. 电视节目")
+		choice := requireInput("请输入选项（1或2）: ")
+
+		switch choice {
+		case "1":
+			mediaType = tmdb.MediaTypeMovie
+		case "2":
+			mediaType = tmdb.MediaTypeTV
+		default:
+			errorf("无效的选项，程序退出")
+			os.Exit(1)
 		}
 	}
 
+	if tmdbID == 0 {
+		input := requireInput("请输入TMDB ID（或输入名称搜索）: ")
+		if tmdbID, err = strconv.Atoi(input); err != nil && input != "" && !*both {
+			var results []tmdb.MovieResponse
+			err = request(ctx, *timeout, func(ctx context.Context) (err error) {
+				results, err = client.Search(ctx, mediaType, input)
+				return err
+			})
+			if err != nil {
+				errorf("搜索失败: %v", err)
+				os.Exit(1)
+			}
+			year, _ := strconv.Atoi(recognition.ParsePath(files[0], parseOpts).Year)
+			tmdbID = pickCandidate(ctx, *timeout, client, mediaType, results, year, *autoPickFirst)
+		}
+		if tmdbID <= 0 {
+			errorf("无效的TMDB ID，程序退出")
+			os.Exit(1)
+		}
+	}
+
+	// -both 同时按电影和电视剧查询该ID，由用户选择正确的一种
+	var movie *tmdb.MovieResponse
+	if *both {
+		mediaType, movie, err = pickInterpretation(ctx, *timeout, client, tmdbID)
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	// 不默认第一季时，只有集数、文件夹中也没有季数的文件不参与生成规则和重命名，列出后由用户手动处理
+	var unresolved []string
+	if mediaType == tmdb.MediaTypeTV && *noDefaultSeason {
+		resolved := files[:0:0]
+		for _, file := range files {
+			if recognition.ParsePath(file, parseOpts).DefaultSeason {
+				unresolved = append(unresolved, file)
+			} else {
+				resolved = append(resolved, file)
+			}
+		}
+		if len(unresolved) > 0 {
+			fmt.Printf("\n=== 无法确定季数的文件（%d个，已排除，请手动处理） ===\n", len(unresolved))
+			for _, file := range unresolved {
+				fmt.Println(file)
+			}
+		}
+		if len(resolved) == 0 {
+			errorf("所有匹配的文件都无法确定季数，程序退出")
+			os.Exit(1)
+		}
+		files = resolved
+	}
+
 	firstFile := filepath.Base(files[0])
-	fileInfo := parseFileName(firstFile)
+	fileInfo := recognition.ParsePath(files[0], parseOpts)
 
-	if mediaType == MediaTypeTV {
+	if mediaType == tmdb.MediaTypeTV {
 		if fileInfo.Season == "" {
 			fileInfo.Season = "01"
 		}
@@ -441,65 +944,444 @@ func main() {
 		}
 	}
 
+	// 规则中的视频格式取自第一个文件，与多数文件不同时提示，-majority-format 时改用多数文件的格式
+	if format, count := recognition.MajorityFormat(files, parseOpts); format != "" && format != fileInfo.VideoFormat {
+		if *majorityFormat {
+			infof("第一个文件 %s 的视频格式 %q 与多数文件（%d/%d个）的 %q 不同，已改用 %q", firstFile, fileInfo.VideoFormat, count, len(files), format, format)
+			fileInfo.VideoFormat = format
+		} else {
+			warnf("第一个文件 %s 的视频格式 %q 与多数文件（%d/%d个）的 %q 不同，规则中仍使用 %q，可使用 -majority-format 改用多数文件的格式", firstFile, fileInfo.VideoFormat, count, len(files), format, fileInfo.VideoFormat)
+		}
+	}
+
 	if fileInfo.VideoFormat == "" {
 		fileInfo.VideoFormat = getInput("未从文件名解析出视频格式，请手动输入(如: 1080P): ")
 	}
 
-	url := fmt.Sprintf("%s/%s/%d?api_key=%s&language=zh-CN", baseURL, mediaType, tmdbID, apiKey)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		fmt.Printf("创建请求失败: %v\n", err)
-		os.Exit(1)
+	// 按其他日期确定电影年份时附加各地区的上映日期，-providers 时附加观看渠道；-both 查询的详情中没有这些内容，需要重新请求
+	var appendTo []string
+	if mediaType == tmdb.MediaTypeMovie && *yearSource != tmdb.YearSourceRelease {
+		appendTo = append(appendTo, tmdb.AppendReleaseDates)
+	}
+	if *providers {
+		appendTo = append(appendTo, tmdb.AppendWatchProviders)
+	}
+	if movie == nil || len(appendTo) > 0 {
+		err = request(ctx, *timeout, func(ctx context.Context) (err error) {
+			movie, err = client.Details(ctx, mediaType, tmdbID, appendTo...)
+			return err
+		})
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(1)
+		}
 	}
 
-	req.Header.Add("accept", "application/json")
+	// 批量规则通过分组引用匹配文件中的原始季集，按季分组等生成批量规则的步骤不使用剧集组映射
+	batchParseOpts := parseOpts
+	if mediaType == tmdb.MediaTypeTV && *episodeGroupID != "" {
+		var group *tmdb.EpisodeGroupResponse
+		err := request(ctx, *timeout, func(ctx context.Context) (err error) {
+			group, err = client.EpisodeGroup(ctx, *episodeGroupID)
+			return err
+		})
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(1)
+		}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("发送请求失败: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
+		fmt.Printf("\n=== 剧集组「%s」 ===\n", group.Name)
+		for _, file := range files {
+			info := recognition.ParsePath(file, parseOpts)
+			season, _ := strconv.Atoi(info.Season)
+			episode, err := strconv.Atoi(info.Episode)
+			if err != nil {
+				continue
+			}
+			mapped, err := tmdb.MapEpisodeThroughGroup(group, season, episode)
+			if err != nil {
+				warnf("%s 剧集组映射失败，使用默认排序：%v", filepath.Base(file), err)
+				continue
+			}
+			fmt.Printf("%s: S%sE%s -> S%02dE%02d %s\n", filepath.Base(file), info.Season, info.Episode, mapped.SeasonNumber, mapped.EpisodeNumber, mapped.Name)
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("读取响应失败: %v\n", err)
-		os.Exit(1)
+		// 之后逐个文件解析出的季集（单文件规则、-per-file、-apply、-validate、-write-nfo）都是映射后的季集
+		parseOpts.EpisodeMap = func(season, episode int) (int, int, error) {
+			mapped, err := tmdb.MapEpisodeThroughGroup(group, season, episode)
+			if err != nil {
+				return 0, 0, err
+			}
+			return mapped.SeasonNumber, mapped.EpisodeNumber, nil
+		}
+		if info := recognition.ParsePath(files[0], parseOpts); info.Episode != "" {
+			fileInfo.Season, fileInfo.Episode = info.Season, info.Episode
+		}
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("API请求失败，状态码: %d，响应: %s\n", resp.StatusCode, string(body))
+	// 在生成规则前确认查询到的条目，-yes 或非交互模式下跳过
+	if !*yes && isInteractive() && !confirmMetadata(movie) {
+		infof("已取消，程序退出")
 		os.Exit(1)
 	}
 
-	var movie MovieResponse
-	if err := json.Unmarshal(body, &movie); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
-		os.Exit(1)
+	// 确认条目后记录本次的选择，复用记录或 -no-marker 时不写入；
+	// -apply 与 -dest 同时使用时文件会移到目标目录，记录在重命名成功后写入目标目录
+	chosen := marker{TMDBID: tmdbID, MediaType: mediaType, Title: movie.Title}
+	if mediaType == tmdb.MediaTypeTV {
+		chosen.Title = movie.Name
+	}
+	saveMarker := !reusedMarker && !*noMarker
+	if saveMarker && !(*apply && *dest != "") {
+		writeMarkers(dirs, chosen)
 	}
 
-	var title, year string
-	if mediaType == MediaTypeMovie {
+	var title, originalTitle, year, endYear string
+	if mediaType == tmdb.MediaTypeMovie {
 		title = movie.Title
-		year = getYear(movie.ReleaseDate)
+		originalTitle = movie.OriginalTitle
+		year = tmdb.GetYear(movie.MovieDate(*yearSource))
 	} else {
 		title = movie.Name
-		year = getYear(movie.FirstAirDate)
+		originalTitle = movie.OriginalName
+		year = tmdb.GetYear(movie.FirstAirDate)
+		endYear = movie.EndYear()
+	}
+	if year == "" {
+		warnf("TMDB未提供有效的上映/首播日期，输出中将省略年份")
+	}
+
+	if movie.OriginalLanguage != "" {
+		fmt.Printf("\n原始语言: %s，原始标题: %s\n", movie.OriginalLanguage, originalTitle)
+	}
+	if *preferOriginal && originalTitle != "" {
+		title = originalTitle
+	}
+	if *keepFilenameTitle {
+		if t := filenameTitle(firstFile, fixedTitle); t != "" {
+			title = t
+		} else {
+			warnf("未指定标题固定部分或文件名中找不到该标题，-keep-filename-title 未生效")
+		}
+	}
+	if *titleFlag != "" {
+		title = *titleFlag
 	}
 
 	// 显示单个文件的替换规则
-	showRegexRules(firstFile, fixedTitle, title, year, fileInfo, mediaType, movie.ID)
+	template := *templateFlag
+	if template == "" && *presetFlag == "" {
+		template = config.Template
+	}
+	if template == "" {
+		template = recognition.DefaultTVTemplate
+		if preset.TVTemplate != "" {
+			template = preset.TVTemplate
+		}
+		if mediaType == tmdb.MediaTypeMovie {
+			template = recognition.DefaultMovieTemplate
+			if preset.MovieTemplate != "" {
+				template = preset.MovieTemplate
+			}
+		}
+	}
+
+	if config.LocalizedEpisode {
+		template = recognition.LocalizeEpisode(template)
+	}
+
+	data := recognition.NameData{
+		Title:     title,
+		Year:      year,
+		EndYear:   endYear,
+		MediaType: mediaType,
+		TMDBID:    movie.ID,
+		TagFormat: preset.TagFormat,
+		Status:    movie.Status,
+	}
+	if *providers {
+		region := providerRegion(config, client)
+		if data.Provider = movie.StreamingProvider(region); data.Provider != "" {
+			fmt.Printf("\n观看渠道（%s）: %s\n", region, data.Provider)
+		} else {
+			infof("TMDB中没有 %s 地区订阅即可观看的流媒体服务，{provider}为空", region)
+		}
+	}
+	if *tagFormat != "" {
+		data.TagFormat = *tagFormat
+	}
+	data.SeasonPad = max(*seasonPad, 1)
+	data.EpisodePad = max(*episodePad, 1)
+	if *tagType != "" && *tagType != mediaType {
+		warnf("按%s查询TMDB，但输出标签中的类型为%s", mediaType, *tagType)
+		data.TagType = *tagType
+	}
+
+	result := &report{
+		MediaType:      mediaType,
+		TMDBID:         movie.ID,
+		Title:          title,
+		Year:           year,
+		Status:         movie.Status,
+		Provider:       data.Provider,
+		Adult:          movie.Adult,
+		Files:          files,
+		UnmatchedFiles: []string{},
+		Unresolved:     unresolved,
+		Duplicates:     duplicates,
+	}
+
+	patternOpts := recognition.PatternOptions{
+		KeepBetween:    *keepBetween,
+		Seasons:        onlySeasons,
+		EpisodeFrom:    episodeFrom,
+		EpisodeTo:      episodeTo,
+		BackrefStyle:   *backrefStyle,
+		WholeName:      *wholeName,
+		MajorityFormat: *majorityFormat,
+	}
+	// 批量规则匹配的是文件名中的原始集数
+	if episodeTo > 0 {
+		patternOpts.EpisodeFrom += *episodeOffset
+		patternOpts.EpisodeTo += *episodeOffset
+	}
+	if *keepBetween && !strings.Contains(template, "{between}") {
+		template = strings.Replace(template, "{title}", "{title}{between}", 1)
+	}
+
+	// 同一部电影分为多个文件时，默认在文件名中保留分段标记，避免重命名后各部分的文件名相同
+	if mediaType == tmdb.MediaTypeMovie {
+		if parts := movieParts(files, parseOpts); len(parts) > 0 {
+			printMovieParts(parts, parseOpts)
+			if *partsMode == partsKeep {
+				template = withPart(template)
+			} else if !strings.Contains(template, "{part}") {
+				warnf("模板中没有{part}，各部分会生成相同的文件名，-apply 时只重命名第一个部分，其余的作为错误列出")
+			}
+			if !*perFile {
+				infof("注意：单文件规则只针对第一个文件，可使用 -per-file 为每个部分分别生成规则")
+			}
+		}
+	}
+
+	// 按剧集组映射后的季集无法通过分组引用得到，单文件规则改为以完整文件名为被替换词
+	ruleTitle := fixedTitle
+	if parseOpts.EpisodeMap != nil {
+		ruleTitle = ""
+	}
+	singleRule := showRegexRules(firstFile, ruleTitle, template, fileInfo, data, patternOpts)
+	result.Rule = &singleRule
 
 	// 如果是电视剧，还要显示批量替换规则
-	if mediaType == MediaTypeTV {
-		prefix, suffix, videoFormat := generateRegexPattern(files, fixedTitle)
-		if prefix != "" && suffix != "" {
-			showBatchRegexRules(prefix, suffix, fixedTitle, title, year, videoFormat, movie.ID)
+	if mediaType == tmdb.MediaTypeTV {
+		// 标题位于文件名开头时共同前缀为空，后缀为空说明第一个文件中找不到标题或SxxExx
+		prefix, suffix, format := recognition.GenerateRegexPattern(files, fixedTitle, patternOpts, batchParseOpts)
+		if format == "" {
+			format = fileInfo.VideoFormat
+		}
+		if fixedTitle == "" {
+			fmt.Println("\n未指定标题固定部分，不生成批量规则，可使用 -apply 按集数直接重命名")
+		} else if *allSeasons {
+			result.SeasonRules = showSeasonBatchRules(groupBySeason(files, batchParseOpts), fixedTitle, template, fileInfo, data, patternOpts, batchParseOpts)
+		} else if suffix != "" {
+			batchRule := showBatchRegexRules(prefix, suffix, fixedTitle, template, format, fileInfo, data, patternOpts)
+			result.BatchRule = &batchRule
+			previewBatchRule(files, batchRule, patternOpts)
+		}
+
+		if result.BatchRule != nil || len(result.SeasonRules) > 0 {
+			if len(dirs) > 0 {
+				extra, err := findExtraMatches(dirs, recognition.BuildBatchMatchPattern(fixedTitle, patternOpts), files, levels)
+				if err != nil {
+					warnf("无法校验批量匹配模式：%v", err)
+				} else if len(extra) > 0 {
+					warnf("批量匹配模式还会命中以下%d个不在匹配集合中的文件，请确认规则不会误改：\n  %s", len(extra), strings.Join(extra, "\n  "))
+				}
+			}
+			if *episodeGroupID != "" {
+				infof("注意：批量规则无法按剧集组映射集数，仍使用文件中的原始季集")
+			}
+			if *episodeOffset != 0 {
+				infof("注意：正则规则中的集数来自分组引用，无法减去集数偏移量，请使用 -per-file 或 -apply")
+			}
+			if *seasonPad != 2 || *episodePad != 2 {
+				infof("注意：批量规则中的季集来自分组引用，保持文件中的原始位数，-season-pad/-episode-pad 仅对单文件规则和 -apply 生效")
+			}
 		}
 	}
 
-	fmt.Print("\n按回车键退出...")
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
+	// 为每个文件单独输出以完整文件名为被替换词的规则
+	if *perFile {
+		fmt.Println("\n=== 逐个文件的替换规则 ===")
+		for _, file := range files {
+			name := filepath.Base(file)
+			if _, n := recognition.SplitPart(name); n > 1 {
+				continue // 同一组分卷的规则相同，只为第一个分卷输出
+			}
+			info := recognition.ParsePath(file, parseOpts)
+			if mediaType == tmdb.MediaTypeTV && info.Episode == "" {
+				warnf("无法从文件名解析出集数，已跳过: %s", name)
+				continue
+			}
+			if info.VideoFormat == "" {
+				info.VideoFormat = fileInfo.VideoFormat
+			}
+			fileData := data
+			if *keepBetween {
+				fileData.Between = recognition.BetweenText(name, fixedTitle)
+			}
+			result.FileRules = append(result.FileRules, showRegexRules(name, "", template, info, fileData, patternOpts))
+		}
+	}
+
+	if *clipboard {
+		copyRules(result)
+	}
+	if *exportRulesFlag != "" {
+		if rules := selectedRules(result); len(rules) > 0 {
+			if err := exportRules(*exportRulesFlag, rules); err != nil {
+				warnf("导出规则失败：%v", err)
+			} else {
+				infof("\n已将%d条规则导出到 %s", len(rules), *exportRulesFlag)
+			}
+		}
+	}
+
+	if mediaType == tmdb.MediaTypeTV && *validate {
+		seasons := make(map[int]*tmdb.SeasonResponse)
+		for _, season := range fileSeasons(files, parseOpts) {
+			var resp *tmdb.SeasonResponse
+			err := request(ctx, *timeout, func(ctx context.Context) (err error) {
+				resp, err = client.Season(ctx, tmdbID, season)
+				return err
+			})
+			if err != nil {
+				warnf("获取第%d季信息失败：%v", season, err)
+				continue
+			}
+			seasons[season] = resp
+		}
+
+		fmt.Println("\n=== 集数校验 ===")
+		warnings := validateEpisodes(files, seasons, parseOpts, time.Now())
+		for _, w := range warnings {
+			fmt.Println(w)
+		}
+		if len(warnings) == 0 {
+			fmt.Println("所有文件的集数均与TMDB一致")
+		}
+	}
+
+	// 列出目录中存在但未被匹配的视频文件，便于发现遗漏
+	if len(dirs) > 0 {
+		// 被 -no-default-season、-dedupe 排除的文件已单独列出，不再算作未匹配
+		known := slices.Concat(files, unresolved, duplicates)
+		unmatched, err := findExtraMatches(dirs, recognition.VideoFilePattern, known, levels)
+		if err != nil {
+			warnf("无法列出未匹配的文件：%v", err)
+		} else {
+			// 原盘中的文件已作为原盘目录整体处理
+			unmatched = slices.DeleteFunc(unmatched, func(file string) bool {
+				_, inDisc := recognition.DiscRoot(file)
+				return inDisc
+			})
+			if len(unmatched) > 0 {
+				fmt.Printf("\n=== 未匹配文件（%d个） ===\n", len(unmatched))
+				for _, file := range unmatched {
+					fmt.Println(file)
+				}
+				result.UnmatchedFiles = unmatched
+			}
+		}
+	}
+
+	var fileErrors []fileError
+	var items []renameItem
+	applied := false
+	if *apply || *planOut != "" {
+		var errs []fileError
+		items, errs = planRenames(files, renameOptions{
+			Template: template,
+			Data:     data,
+			Fallback: fileInfo,
+			Parse:    parseOpts,
+			Sidecars: *sidecars,
+			Dest:     *dest,
+		})
+		fileErrors = append(fileErrors, errs...)
+
+		fmt.Println("\n=== 重命名计划 ===")
+		for _, item := range items {
+			target := item.NewPath
+			if *dest == "" {
+				// 原地重命名时显示相对原目录的路径，模板生成的子目录也能看出来
+				if rel, err := filepath.Rel(filepath.Dir(item.OldPath), item.NewPath); err == nil {
+					target = rel
+				}
+			}
+			fmt.Printf("%s -> %s\n", item.OldPath, target)
+		}
+		if *planOut != "" {
+			if err := writePlan(*planOut, items); err != nil {
+				warnf("写入重命名计划失败：%v", err)
+			} else {
+				infof("\n已将%d项重命名计划写入 %s，检查或修改后可使用 -apply-plan %s 执行", len(items), *planOut, *planOut)
+			}
+		}
+		if *apply && len(items) > 0 && (*yes || strings.EqualFold(getInput("\n确认执行重命名？(y/N): "), "y")) {
+			errs, conflicts := applyRenames(items, *onConflict)
+			fileErrors = append(fileErrors, errs...)
+			printConflictReport(conflicts)
+			result.addConflicts(conflicts)
+			applied = true
+			if saveMarker && *dest != "" && len(errs) == 0 {
+				writeMarkers([]string{*dest}, chosen)
+			}
+			if *printPaths {
+				printFinalPaths(items)
+			}
+		}
+		// 执行后记录，追加序号等处理后的实际位置也会写入结果
+		result.addRenames(items)
+	}
+
+	if *writeNFO {
+		if mediaType != tmdb.MediaTypeTV {
+			infof("注意：-write-nfo 仅支持电视剧的单集元数据")
+		} else {
+			locations := make(map[string]string)
+			for _, item := range items {
+				if item.Moved {
+					locations[item.OldPath] = item.NewPath
+				}
+			}
+			fileErrors = append(fileErrors, writeEpisodeNFOs(ctx, *timeout, client, tmdbID, files, locations, parseOpts, applied)...)
+		}
+	}
+	printErrorReport(fileErrors)
+
+	// 本次运行中TMDB返回404的条目，同一条目只请求一次
+	result.NotFound = client.NotFound()
+	if len(result.NotFound) > 0 {
+		fmt.Printf("\n=== TMDB中不存在的条目（%d个） ===\n", len(result.NotFound))
+		for _, path := range result.NotFound {
+			fmt.Println(path)
+		}
+	}
+
+	if *jsonOut != "" {
+		result.addErrors(fileErrors)
+		if err := writeReport(*jsonOut, result); err != nil {
+			errorf("写入JSON结果失败: %v", err)
+		}
+	}
+
+	if !noPrompt {
+		fmt.Print("\n按回车键退出...")
+		stdin.ReadBytes('\n')
+	}
+
+	if len(fileErrors) > 0 {
+		os.Exit(1)
+	}
 }
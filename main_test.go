@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+func TestParseEpisodeRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to int
+		wantErr  bool
+	}{
+		{"", 0, 0, false},
+		{"5-12", 5, 12, false},
+		{" 5 - 12 ", 5, 12, false},
+		{"7", 7, 7, false},
+		{"0-3", 0, 3, false},
+		{"12-5", 0, 0, true},
+		{"-3", 0, 0, true},
+		{"a-b", 0, 0, true},
+		{"5-", 0, 0, true},
+		{"1-2-3", 0, 0, true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseEpisodeRange(tt.input)
+		if from != tt.from || to != tt.to || (err != nil) != tt.wantErr {
+			t.Errorf("parseEpisodeRange(%q) = %d, %d, %v, want %d, %d, error %v", tt.input, from, to, err, tt.from, tt.to, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSeasonList(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"2,4", []int{2, 4}, false},
+		{" 4 , 2, 4,, ", []int{4, 2}, false},
+		{"0", []int{0}, false},
+		{"2,x", nil, true},
+		{"-1", nil, true},
+		{"1-3", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSeasonList(tt.input)
+		if !slices.Equal(got, tt.want) || (err != nil) != tt.wantErr {
+			t.Errorf("parseSeasonList(%q) = %v, %v, want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1024", 1024, false},
+		{"100B", 100, false},
+		{"50MB", 50 << 20, false},
+		{"50m", 50 << 20, false},
+		{"1.5GB", 3 << 29, false},
+		{" 2 KB ", 2 << 10, false},
+		{"1TB", 1 << 40, false},
+		{"10XB", 0, true},
+		{"MB", 0, true},
+		{"-5MB", 0, true},
+		{"1,5GB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.input)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseSize(%q) = %d, %v, want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseMediaID(t *testing.T) {
+	tests := []struct {
+		input     string
+		mediaType string
+		id        int
+		wantErr   bool
+	}{
+		{"tv:1396", tmdb.MediaTypeTV, 1396, false},
+		{"movie:603", tmdb.MediaTypeMovie, 603, false},
+		{" TV : 1396 ", tmdb.MediaTypeTV, 1396, false},
+		{"1396", "", 0, true},
+		{"tmdb:1396", "", 0, true},
+		{"show:1396", "", 0, true},
+		{"tv:abc", "", 0, true},
+		{"tv:", "", 0, true},
+		{"tv:0", "", 0, true},
+		{"movie:-603", "", 0, true},
+	}
+	for _, tt := range tests {
+		mediaType, id, err := parseMediaID(tt.input)
+		if mediaType != tt.mediaType || id != tt.id || (err != nil) != tt.wantErr {
+			t.Errorf("parseMediaID(%q) = %q, %d, %v, want %q, %d, error %v", tt.input, mediaType, id, err, tt.mediaType, tt.id, tt.wantErr)
+		}
+	}
+}
+
+func TestTitleRegex(t *testing.T) {
+	tests := []struct {
+		expr    string
+		files   []string
+		title   string // 为空表示提取不到，回退到手动输入
+		wantErr bool
+	}{
+		{`^(?P<title>.+?)\.S\d+E\d+`, []string{"/media/Show.Name.S01E01.1080p.mkv"}, "Show.Name", false},
+		{`^\[.+?\] (?P<title>.+?) - \d+`, []string{"readme.txt", "[Group] Show Name - 01 [1080p].mkv"}, "Show Name", false},
+		{`^(?P<title>\s*)\.S\d+E\d+`, []string{" .S01E01.mkv"}, "", false},
+		{`^(?P<title>.+?)\.S\d+E\d+`, []string{"Movie.2010.1080p.mkv"}, "", false},
+		{`^(?P<name>.+?)\.S\d+E\d+`, nil, "", true},
+		{`^(.+?)\.S\d+E\d+`, nil, "", true},
+		{`^(?P<title>.+?`, nil, "", true},
+	}
+	for _, tt := range tests {
+		re, err := parseTitleRegex(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTitleRegex(%q) error = %v, want error %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		title, name := regexTitle(re, tt.files)
+		if title != tt.title || (title != "" && name == "") || (title == "" && name != "") {
+			t.Errorf("regexTitle(%q, %q) = %q, %q, want title %q", tt.expr, tt.files, title, name, tt.title)
+		}
+	}
+}
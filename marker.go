@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// markerFileName 记录目录上次所选TMDB条目的文件，再次处理该目录时可以直接复用
+const markerFileName = ".custom-recognition"
+
+// marker 目录中记录的TMDB条目
+type marker struct {
+	TMDBID    int    `json:"tmdbid"`
+	MediaType string `json:"type"`
+	Title     string `json:"title"`
+}
+
+// readMarker 读取目录中的记录，不存在时返回nil
+func readMarker(dir string) (*marker, error) {
+	data, err := os.ReadFile(filepath.Join(dir, markerFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m marker
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// findMarker 返回第一个带有有效记录的目录中的记录，都没有时返回nil
+func findMarker(dirs []string) (*marker, string) {
+	for _, dir := range dirs {
+		m, err := readMarker(dir)
+		if err != nil {
+			continue
+		}
+		if m != nil && m.TMDBID > 0 && (m.MediaType == tmdb.MediaTypeMovie || m.MediaType == tmdb.MediaTypeTV) {
+			return m, dir
+		}
+	}
+	return nil, ""
+}
+
+// reusableMarker 返回可以复用的记录及其所在目录：已通过 -id 指定ID、使用 -both 或 -ignore-marker 时不复用，返回nil
+func reusableMarker(dirs []string, tmdbID int, both, ignore bool) (*marker, string) {
+	if tmdbID != 0 || both || ignore {
+		return nil, ""
+	}
+	return findMarker(dirs)
+}
+
+// writeMarker 将本次选择的TMDB条目写入目录，覆盖原有的记录
+func writeMarker(dir string, m marker) error {
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, markerFileName), append(data, '\n'), 0644)
+}
+
+// writeMarkers 将本次选择的TMDB条目写入每个目录，写入失败时给出警告并继续
+func writeMarkers(dirs []string, m marker) {
+	for _, dir := range dirs {
+		if err := writeMarker(dir, m); err != nil {
+			warnf("无法写入 %s：%v", filepath.Join(dir, markerFileName), err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+func TestMarkerRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if m, err := readMarker(dir); m != nil || err != nil {
+		t.Errorf("readMarker() without marker = %+v, %v, want nil, nil", m, err)
+	}
+	want := marker{TMDBID: 1396, MediaType: tmdb.MediaTypeTV, Title: "绝命毒师"}
+	if err := writeMarker(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readMarker(dir)
+	if err != nil || got == nil || *got != want {
+		t.Errorf("readMarker() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestReusableMarker(t *testing.T) {
+	empty := t.TempDir()
+	invalid := t.TempDir()
+	writeFile(t, filepath.Join(invalid, markerFileName), "not json")
+	unknownType := t.TempDir()
+	if err := writeMarker(unknownType, marker{TMDBID: 1, MediaType: "person"}); err != nil {
+		t.Fatal(err)
+	}
+	valid := t.TempDir()
+	if err := writeMarker(valid, marker{TMDBID: 603, MediaType: tmdb.MediaTypeMovie, Title: "黑客帝国"}); err != nil {
+		t.Fatal(err)
+	}
+	dirs := []string{empty, invalid, unknownType, valid}
+
+	tests := []struct {
+		name   string
+		dirs   []string
+		tmdbID int
+		both   bool
+		ignore bool
+		want   string // 复用的记录所在的目录，为空表示不复用
+	}{
+		{"跳过没有记录和无效记录的目录", dirs, 0, false, false, valid},
+		{"没有有效记录", dirs[:3], 0, false, false, ""},
+		{"已指定ID", dirs, 603, false, false, ""},
+		{"-both", dirs, 0, true, false, ""},
+		{"-ignore-marker", dirs, 0, false, true, ""},
+	}
+	for _, tt := range tests {
+		m, dir := reusableMarker(tt.dirs, tt.tmdbID, tt.both, tt.ignore)
+		if dir != tt.want || (m != nil) != (tt.want != "") {
+			t.Errorf("%s: reusableMarker() = %+v, %q, want %q", tt.name, m, dir, tt.want)
+		}
+		if m != nil && m.TMDBID != 603 {
+			t.Errorf("%s: reusableMarker() TMDBID = %d, want 603", tt.name, m.TMDBID)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(empty, markerFileName)); !os.IsNotExist(err) {
+		t.Errorf("reusableMarker() should not create %s", markerFileName)
+	}
+}
+
+func TestWriteMarkers(t *testing.T) {
+	dirs := []string{filepath.Join(t.TempDir(), "missing"), t.TempDir()}
+	want := marker{TMDBID: 1, MediaType: tmdb.MediaTypeMovie, Title: "电影"}
+	// 无法写入的目录只给出警告，不影响其余目录
+	writeMarkers(dirs, want)
+	if _, err := os.Stat(filepath.Join(dirs[0], markerFileName)); !os.IsNotExist(err) {
+		t.Errorf("writeMarkers() wrote into a missing directory, Stat() error = %v", err)
+	}
+	if got, err := readMarker(dirs[1]); err != nil || got == nil || *got != want {
+		t.Errorf("readMarker() = %+v, %v, want %+v", got, err, want)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// episodeNFO Kodi/Jellyfin/Emby 通用的单集 .nfo 元数据
+type episodeNFO struct {
+	XMLName xml.Name `xml:"episodedetails"`
+	Title   string   `xml:"title"`
+	Season  int      `xml:"season"`
+	Episode int      `xml:"episode"`
+	Aired   string   `xml:"aired,omitempty"`
+	Runtime int      `xml:"runtime,omitempty"`
+	Plot    string   `xml:"plot,omitempty"`
+	IDs     []nfoID  `xml:"uniqueid"`
+}
+
+type nfoID struct {
+	Type  string `xml:"type,attr"`
+	Value int    `xml:",chardata"`
+}
+
+var videoFileRegex = regexp.MustCompile(recognition.VideoFilePattern)
+
+// nfoPath 返回与视频同名的 .nfo 路径
+func nfoPath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, recognition.FileExt(videoPath)) + ".nfo"
+}
+
+// writeEpisodeNFOs 为每个视频文件获取TMDB单集详情，在视频旁写入同名 .nfo；
+// locations为视频当前所在的位置（已重命名时为新路径），write为false时只输出将要写入的内容；
+// 已存在的 .nfo 不会被覆盖
+func writeEpisodeNFOs(ctx context.Context, timeout time.Duration, client *tmdb.Client, tvID int, files []string, locations map[string]string, opts recognition.ParseOptions, write bool) []fileError {
+	var errs []fileError
+	if write {
+		fmt.Println("\n=== 写入单集元数据 ===")
+	} else {
+		fmt.Println("\n=== 单集元数据（未执行重命名，仅预览，不写入文件） ===")
+	}
+
+	for _, file := range files {
+		if !videoFileRegex.MatchString(file) {
+			continue
+		}
+		// 同一组分卷共用一个 .nfo，只在第一个分卷时写入
+		if _, n := recognition.SplitPart(file); n > 1 {
+			continue
+		}
+		info := recognition.ParsePath(file, opts)
+		season, _ := strconv.Atoi(info.Season)
+		episode, err := strconv.Atoi(info.Episode)
+		if err != nil {
+			errs = append(errs, fileError{Path: file, Err: fmt.Errorf("无法从文件名解析出集数，未生成元数据")})
+			continue
+		}
+
+		var ep *tmdb.EpisodeResponse
+		err = request(ctx, timeout, func(ctx context.Context) (err error) {
+			ep, err = client.Episode(ctx, tvID, season, episode)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fileError{Path: file, Err: fmt.Errorf("获取单集详情失败: %v", err)})
+			continue
+		}
+
+		location := file
+		if loc, ok := locations[file]; ok {
+			location = loc
+		}
+		path := nfoPath(location)
+		content, err := xml.MarshalIndent(episodeNFO{
+			Title:   ep.Name,
+			Season:  season,
+			Episode: episode,
+			Aired:   ep.AirDate,
+			Runtime: ep.Runtime,
+			Plot:    ep.Overview,
+			IDs:     []nfoID{{Type: "tmdb", Value: ep.ID}},
+		}, "", "    ")
+		if err != nil {
+			errs = append(errs, fileError{Path: file, Err: err})
+			continue
+		}
+
+		fmt.Printf("%s: S%02dE%02d %s（播出: %s，时长: %d分钟）\n", path, season, episode, ep.Name, ep.AirDate, ep.Runtime)
+		if !write {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("  已存在，跳过: %s\n", path)
+			continue
+		}
+		data := append([]byte(xml.Header), content...)
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			errs = append(errs, fileError{Path: file, Err: fmt.Errorf("写入元数据失败: %v", err)})
+		}
+	}
+	return errs
+}
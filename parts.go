@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+)
+
+// 电影分为多个文件（CD1/CD2、Disc 1/Disc 2）时的处理方式
+const (
+	partsKeep = "keep" // 在文件名末尾保留分段标记
+	partsFlag = "flag" // 只标记为同一部电影的多个部分，不修改模板
+)
+
+// movieParts 返回带分段标记的文件
+func movieParts(files []string, opts recognition.ParseOptions) []string {
+	var parts []string
+	for _, file := range files {
+		if recognition.ParsePath(file, opts).Part != "" {
+			parts = append(parts, file)
+		}
+	}
+	return parts
+}
+
+// printMovieParts 列出同一部电影的各个分段文件
+func printMovieParts(parts []string, opts recognition.ParseOptions) {
+	fmt.Printf("\n=== 分段文件（同一部电影的%d个部分） ===\n", len(parts))
+	for _, file := range parts {
+		fmt.Printf("%s: %s\n", recognition.ParsePath(file, opts).Part, filepath.Base(file))
+	}
+}
+
+// withPart 在模板末尾追加{part}，以 . 连接占位符的模板用 . 分隔，否则按媒体服务器的分段命名习惯用 " - " 分隔
+func withPart(template string) string {
+	if strings.Contains(template, "{part}") {
+		return template
+	}
+	if strings.Contains(template, "}.{") {
+		return template + ".{part}"
+	}
+	return template + " - {part}"
+}
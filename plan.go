@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// renamePlan -plan 写入、-apply-plan 读取的重命名计划，可在两步之间手动检查和修改
+type renamePlan struct {
+	Renames []renameReport `json:"renames"`
+}
+
+// writePlan 将重命名计划以JSON格式写入文件，路径转换为绝对路径，便于在其他工作目录中执行
+func writePlan(path string, items []renameItem) error {
+	p := renamePlan{Renames: []renameReport{}}
+	for _, item := range items {
+		oldPath, err := filepath.Abs(item.OldPath)
+		if err != nil {
+			return err
+		}
+		newPath, err := filepath.Abs(item.NewPath)
+		if err != nil {
+			return err
+		}
+		p.Renames = append(p.Renames, renameReport{Old: oldPath, New: newPath})
+	}
+	data, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readPlan 读取重命名计划，old或new为空的条目视为格式错误
+func readPlan(path string) ([]renameItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p renamePlan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("重命名计划 %s 格式错误: %v", path, err)
+	}
+	items := make([]renameItem, 0, len(p.Renames))
+	for i, r := range p.Renames {
+		if r.Old == "" || r.New == "" {
+			return nil, fmt.Errorf("重命名计划 %s 第%d项缺少 old 或 new", path, i+1)
+		}
+		items = append(items, renameItem{OldPath: r.Old, NewPath: r.New})
+	}
+	return items, nil
+}
+
+// checkPlan 重新校验计划中的每一项：源文件必须仍然存在，目标文件不能与其他项重复；
+// 不满足的项作为错误返回，不会执行。已存在的目标文件由执行时的 -on-conflict 策略处理。
+// 返回的项按orderRenames排序
+func checkPlan(items []renameItem) ([]renameItem, []fileError) {
+	var valid []renameItem
+	var errs []fileError
+	targets := make(map[string]string)
+	for _, item := range items {
+		if item.OldPath == item.NewPath {
+			continue
+		}
+		if _, err := os.Stat(item.OldPath); err != nil {
+			errs = append(errs, fileError{Path: item.OldPath, Err: fmt.Errorf("源文件不存在或无法访问: %v", err)})
+			continue
+		}
+		if _, err := os.Stat(item.NewPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fileError{Path: item.OldPath, Err: fmt.Errorf("无法检查目标文件: %v", err)})
+			continue
+		}
+		target := filepath.Clean(item.NewPath)
+		if other, ok := targets[target]; ok {
+			errs = append(errs, fileError{Path: item.OldPath, Err: fmt.Errorf("目标文件与 %s 相同: %s", other, item.NewPath)})
+			continue
+		}
+		targets[target] = item.OldPath
+		valid = append(valid, item)
+	}
+	valid, cycleErrs := orderRenames(valid)
+	return valid, append(errs, cycleErrs...)
+}
+
+// applyPlan 读取并重新校验重命名计划，确认后执行其中可以执行的项，目标文件已存在时按onConflict处理，
+// printPaths时输出执行后的绝对路径；返回是否全部成功
+func applyPlan(path string, yes bool, onConflict string, printPaths bool) bool {
+	items, err := readPlan(path)
+	if err != nil {
+		errorf("读取重命名计划失败: %v", err)
+		return false
+	}
+	valid, errs := checkPlan(items)
+
+	fmt.Printf("\n=== 重命名计划（共%d项，%d项可以执行） ===\n", len(items), len(valid))
+	for _, item := range valid {
+		fmt.Printf("%s -> %s\n", item.OldPath, item.NewPath)
+	}
+	if len(valid) > 0 && (yes || strings.EqualFold(getInput("\n确认执行重命名？(y/N): "), "y")) {
+		applyErrs, conflicts := applyRenames(valid, onConflict)
+		errs = append(errs, applyErrs...)
+		printConflictReport(conflicts)
+		if printPaths {
+			printFinalPaths(valid)
+		}
+	}
+	printErrorReport(errs)
+	return len(errs) == 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.json")
+	items := []renameItem{{OldPath: filepath.Join(dir, "a.mkv"), NewPath: filepath.Join(dir, "b.mkv")}}
+	if err := writePlan(path, items); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readPlan(path)
+	if err != nil || len(got) != 1 || got[0] != items[0] {
+		t.Errorf("readPlan() = %+v, %v, want %+v", got, err, items)
+	}
+}
+
+func TestReadPlanErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string // 错误信息中应包含的内容
+	}{
+		{"格式错误", `{"renames": [`, "格式错误"},
+		{"类型错误", `{"renames": {"old": "a"}}`, "格式错误"},
+		{"缺少new", `{"renames": [{"old": "a.mkv", "new": "b.mkv"}, {"old": "c.mkv"}]}`, "第2项缺少 old 或 new"},
+		{"缺少old", `{"renames": [{"new": "b.mkv"}]}`, "第1项缺少 old 或 new"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "plan.json")
+		writeFile(t, path, tt.content)
+		if _, err := readPlan(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: readPlan() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := readPlan(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("readPlan() for a missing file error = %v, want not exist", err)
+	}
+}
+
+func TestCheckPlan(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	writeFile(t, path("a.mkv"), "")
+	writeFile(t, path("b.mkv"), "")
+	writeFile(t, path("c.mkv"), "")
+	writeFile(t, path("same.mkv"), "")
+	writeFile(t, path("exists.mkv"), "")
+
+	items := []renameItem{
+		{OldPath: path("a.mkv"), NewPath: path("A.2020.mkv")},
+		{OldPath: path("missing.mkv"), NewPath: path("M.2020.mkv")},
+		{OldPath: path("b.mkv"), NewPath: path("exists.mkv")},
+		{OldPath: path("c.mkv"), NewPath: path("sub/../A.2020.mkv")},
+		{OldPath: path("same.mkv"), NewPath: path("same.mkv")},
+	}
+	valid, errs := checkPlan(items)
+	// 目标文件已存在的项留给 -on-conflict 处理
+	if len(valid) != 2 || valid[0] != items[0] || valid[1] != items[2] {
+		t.Errorf("checkPlan() valid = %+v, want %+v and %+v", valid, items[0], items[2])
+	}
+
+	want := map[string]string{
+		path("missing.mkv"): "源文件不存在",
+		path("c.mkv"):       "目标文件与 " + path("a.mkv") + " 相同",
+	}
+	if len(errs) != len(want) {
+		t.Errorf("checkPlan() errors = %+v, want %d errors", errs, len(want))
+	}
+	for _, e := range errs {
+		if msg, ok := want[e.Path]; !ok || !strings.Contains(e.Err.Error(), msg) {
+			t.Errorf("checkPlan() error for %s = %v, want %q", e.Path, e.Err, msg)
+		}
+	}
+}
+
+func TestApplyPlanConflict(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	writeFile(t, path("a.mkv"), "a")
+	writeFile(t, path("b.mkv"), "b")
+	writeFile(t, path("c.mkv"), "c")
+	planPath := path("plan.json")
+	// A->B 与 B->C 形成链条，C已存在
+	if err := writePlan(planPath, []renameItem{
+		{OldPath: path("a.mkv"), NewPath: path("b.mkv")},
+		{OldPath: path("b.mkv"), NewPath: path("c.mkv")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !applyPlan(planPath, true, conflictSuffix, false) {
+		t.Errorf("applyPlan() = false, want true")
+	}
+	for name, want := range map[string]string{"a.mkv": "", "b.mkv": "a", "c.mkv": "c", "c (1).mkv": "b"} {
+		if got := readFile(t, path(name)); got != want {
+			t.Errorf("applyPlan(): %s = %q, want %q", name, got, want)
+		}
+	}
+}
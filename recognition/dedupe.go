@@ -0,0 +1,76 @@
+package recognition
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 修正版标记：PROPER、REPACK、RERIP，后面可带版本号，如 REPACK2
+	revisionRegex   = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:PROPER|REPACK|RERIP)(\d?)(?:[^a-z0-9]|$)`)
+	resolutionRegex = regexp.MustCompile(`(\d{3,4})P`)
+)
+
+// Revision 返回文件名中的修正版本：没有 PROPER/REPACK 标记时为0，有标记时为1，REPACK2 等带版本号时为该版本号
+func Revision(fileName string) int {
+	matches := revisionRegex.FindStringSubmatch(fileName)
+	if matches == nil {
+		return 0
+	}
+	if n, err := strconv.Atoi(matches[1]); err == nil && n > 1 {
+		return n
+	}
+	return 1
+}
+
+// resolution 返回视频格式中的分辨率高度，如 2160P.HDR 返回2160，保留的 4K/8K 标签按2160/4320计算，没有时返回0
+func resolution(format string) int {
+	format = strings.ToUpper(format)
+	for label, alias := range resolutionAliases {
+		format = strings.ReplaceAll(format, label, alias)
+	}
+	matches := resolutionRegex.FindStringSubmatch(format)
+	if matches == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(matches[1])
+	return n
+}
+
+// Dedupe 在季集相同的多个文件中只保留一个：修正版本（PROPER/REPACK）高的优先，其次分辨率高的优先，
+// 都相同时保留先出现的一个；同一组分卷（.mkv.001、.mkv.002）视为一个文件，一起保留或跳过；
+// 没有识别出集数的文件原样保留。返回保留的文件（保持原有顺序）和跳过的重复文件
+func Dedupe(files []string, opts ParseOptions) (kept, skipped []string) {
+	type candidate struct {
+		name       string // 去掉分卷后缀后的路径
+		revision   int
+		resolution int
+	}
+	best := make(map[string]candidate)
+	keys := make([]string, len(files))
+	for i, file := range files {
+		info := ParsePath(file, opts)
+		if info.Episode == "" {
+			continue
+		}
+		season, _ := strconv.Atoi(info.Season)
+		episode, _ := strconv.Atoi(info.Episode)
+		key := fmt.Sprintf("S%dE%d", season, episode)
+		keys[i] = key
+		c := candidate{name: SplitBase(file), revision: Revision(filepath.Base(file)), resolution: resolution(info.VideoFormat)}
+		if prev, ok := best[key]; !ok || c.revision > prev.revision || (c.revision == prev.revision && c.resolution > prev.resolution) {
+			best[key] = c
+		}
+	}
+	for i, file := range files {
+		if keys[i] == "" || best[keys[i]].name == SplitBase(file) {
+			kept = append(kept, file)
+		} else {
+			skipped = append(skipped, file)
+		}
+	}
+	return kept, skipped
+}
@@ -0,0 +1,69 @@
+package recognition
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRevision(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Show.S01E01.1080p.WEB-DL.mkv", 0},
+		{"Show.S01E01.PROPER.1080p.WEB-DL.mkv", 1},
+		{"Show.S01E01.REPACK.1080p.mkv", 1},
+		{"Show.S01E01.REPACK2.1080p.mkv", 2},
+		{"Show.S01E01.repack.mkv", 1},
+		{"Improper.Conduct.S01E01.1080p.mkv", 0},
+	}
+	for _, tt := range tests {
+		if got := Revision(tt.name); got != tt.want {
+			t.Errorf("Revision(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	files := []string{
+		"Show.S01E01.720p.mkv",
+		"Show.S01E01.PROPER.720p.mkv",
+		"Show.S01E01.1080p.mkv",
+		"Show.S01E02.720p.mkv",
+		"Show.S01E02.1080p.mkv",
+		"Show.S01E03.REPACK.720p.mkv",
+		"Show.S01E03.REPACK2.720p.mkv",
+		"Show.S1E4.1080p.mkv",
+		"Show.S01E04.1080p.mkv",
+		"Extras.mkv",
+	}
+	kept, skipped := Dedupe(files, ParseOptions{})
+	wantKept := []string{
+		"Show.S01E01.PROPER.720p.mkv",
+		"Show.S01E02.1080p.mkv",
+		"Show.S01E03.REPACK2.720p.mkv",
+		"Show.S1E4.1080p.mkv",
+		"Extras.mkv",
+	}
+	wantSkipped := []string{
+		"Show.S01E01.720p.mkv",
+		"Show.S01E01.1080p.mkv",
+		"Show.S01E02.720p.mkv",
+		"Show.S01E03.REPACK.720p.mkv",
+		"Show.S01E04.1080p.mkv",
+	}
+	if !slices.Equal(kept, wantKept) {
+		t.Errorf("Dedupe() kept = %q, want %q", kept, wantKept)
+	}
+	if !slices.Equal(skipped, wantSkipped) {
+		t.Errorf("Dedupe() skipped = %q, want %q", skipped, wantSkipped)
+	}
+
+	kept, _ = Dedupe([]string{"Show.S01E01.1080p.mkv", "Show.S01E01.4K.mkv"}, ParseOptions{KeepResolutionLabel: true})
+	if !slices.Equal(kept, []string{"Show.S01E01.4K.mkv"}) {
+		t.Errorf("Dedupe() with 4K label kept = %q", kept)
+	}
+	if got := ParseFileName("Show.S01E01.PROPER.720p.mkv").EpisodeTitle; got != "" {
+		t.Errorf("EpisodeTitle = %q, want PROPER not to be part of the episode title", got)
+	}
+}
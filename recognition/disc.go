@@ -0,0 +1,71 @@
+package recognition
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DiscRoot 判断文件是否位于原盘目录结构中（BDMV/STREAM/*.m2ts 或 VIDEO_TS/*.VOB），
+// 是时返回原盘的根目录，即 BDMV 或 VIDEO_TS 所在的目录，其名称通常就是影片标题
+func DiscRoot(path string) (string, bool) {
+	dir := filepath.Dir(path)
+	for {
+		name := filepath.Base(dir)
+		if strings.EqualFold(name, "BDMV") || strings.EqualFold(name, "VIDEO_TS") {
+			return filepath.Dir(dir), true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// isDiscDir 判断目录是否为 BDMV（包含 STREAM 子目录）或 VIDEO_TS 目录
+func isDiscDir(path, name string) bool {
+	if strings.EqualFold(name, "VIDEO_TS") {
+		return true
+	}
+	if !strings.EqualFold(name, "BDMV") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(path, "STREAM"))
+	return err == nil && info.IsDir()
+}
+
+// FindDiscs 递归查找目录中的原盘，返回各原盘的根目录；根目录名称需包含fixedTitle（不区分大小写），为空时返回所有原盘。
+// levels的含义同MatchOptions.Levels，按原盘根目录所在的层数计算
+func FindDiscs(dir, fixedTitle string, levels int) ([]string, error) {
+	var discs []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 与walkFiles相同，跳过无权限读取的子目录
+			if path == dir || !errors.Is(err, fs.ErrPermission) {
+				return err
+			}
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		root := filepath.Dir(path)
+		if !isDiscDir(path, info.Name()) || tooDeep(dir, root, levels) {
+			if tooDeep(dir, path, levels) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.Contains(strings.ToLower(filepath.Base(root)), strings.ToLower(fixedTitle)) {
+			discs = append(discs, root)
+		}
+		return filepath.SkipDir
+	})
+	return discs, err
+}
@@ -0,0 +1,36 @@
+package recognition
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscs(t *testing.T) {
+	files := []string{
+		"Inception.2010.1080p.BluRay/BDMV/STREAM/00000.m2ts",
+		"Inception.2010.1080p.BluRay/BDMV/index.bdmv",
+		"Old.Movie.DVD/VIDEO_TS/VTS_01_1.VOB",
+		"Show.S01E01.1080p.mkv",
+		"Fake/BDMV/notes.txt", // 没有 STREAM 子目录，不是蓝光原盘
+	}
+	dir := writeFixture(t, files...)
+
+	discs, err := FindDiscs(dir, "", 0)
+	if err != nil {
+		t.Fatalf("FindDiscs() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "Inception.2010.1080p.BluRay"), filepath.Join(dir, "Old.Movie.DVD")}
+	if len(discs) != len(want) || discs[0] != want[0] || discs[1] != want[1] {
+		t.Errorf("FindDiscs() = %q, want %q", discs, want)
+	}
+	if discs, _ := FindDiscs(dir, "inception", 0); len(discs) != 1 || discs[0] != want[0] {
+		t.Errorf("FindDiscs(inception) = %q, want %q", discs, want[:1])
+	}
+
+	if root, ok := DiscRoot(fixturePath(dir, files[0])); !ok || root != want[0] {
+		t.Errorf("DiscRoot() = %q, %v, want %q", root, ok, want[0])
+	}
+	if _, ok := DiscRoot(filepath.Join(dir, "Show.S01E01.1080p.mkv")); ok {
+		t.Error("DiscRoot() of a plain file = true, want false")
+	}
+}
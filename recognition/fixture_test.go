@@ -0,0 +1,92 @@
+package recognition
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFixture 在临时目录中创建files列出的空文件（以 / 分隔的相对路径，所在的目录会一并创建），返回该临时目录
+func writeFixture(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, file := range files {
+		path := fixturePath(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// fixturePath 返回fixture目录中以 / 分隔的相对路径对应的完整路径
+func fixturePath(dir, file string) string {
+	return filepath.Join(dir, filepath.FromSlash(file))
+}
+
+// fixtureFile 示例媒体库中的一个文件，video 为 false 的是字幕、元数据等非视频文件；
+// season、episode 为按 ParseOptions{FolderSeason: true} 识别出的季集，为空表示识别不出
+type fixtureFile struct {
+	path            string
+	video           bool
+	season, episode string
+}
+
+// sampleLibrary 有代表性的媒体库：不同的命名方式、季文件夹、字幕、样片、花絮和原盘，
+// 新的命名情况可以直接追加到这里，由 TestSampleLibrary 检查查找和识别的结果
+var sampleLibrary = []fixtureFile{
+	{"Breaking.Bad.S01E01.720p.BluRay.x264-GRP.mkv", true, "01", "01"},
+	{"Breaking.Bad.S01E01.720p.BluRay.x264-GRP.chs.srt", false, "", ""},
+	{"Breaking.Bad.S01E02.720p.BluRay.x264-GRP.mkv", true, "01", "02"},
+	{"Breaking.Bad.S01E02.720p.BluRay.x264-GRP.nfo", false, "", ""},
+	{"Season 2/Breaking.Bad.S02E03.1080p.WEB-DL.mkv", true, "02", "03"},
+	{"Season 2/Breaking.Bad.S02E03.1080p.WEB-DL.zh.ass", false, "", ""},
+	{"Season 3/05.mkv", true, "03", "05"},
+	{"The.Office.1x05.HDTV.avi", true, "01", "05"},
+	{"Show.2019.E12.mp4", true, "01", "12"},
+	{"诛仙.第2季.第03集.mp4", true, "02", "03"},
+	{"第二季/诛仙 第04集.mp4", true, "02", "04"},
+	{"Season 4/Breaking.Bad.S04E01.1080p.mkv.001", true, "04", "01"},
+	{"Season 4/Breaking.Bad.S04E01.1080p.mkv.002", true, "04", "01"},
+	{"Sample/Breaking.Bad.S01E01.sample.mkv", true, "01", "01"},
+	{"Extras/Behind.the.Scenes.mkv", true, "", ""},
+	{"Movie.2010.1080p.BluRay/BDMV/STREAM/00000.m2ts", true, "", ""},
+	{"poster.jpg", false, "", ""},
+}
+
+func TestSampleLibrary(t *testing.T) {
+	var paths, want []string
+	for _, f := range sampleLibrary {
+		paths = append(paths, f.path)
+	}
+	dir := writeFixture(t, paths...)
+	for _, f := range sampleLibrary {
+		if f.video {
+			want = append(want, fixturePath(dir, f.path))
+		}
+	}
+
+	got, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{})
+	if err != nil {
+		t.Fatalf("FindMatchingFiles() error = %v", err)
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("FindMatchingFiles() = %q, want %q", got, want)
+	}
+
+	for _, f := range sampleLibrary {
+		if !f.video {
+			continue
+		}
+		info := ParsePath(fixturePath(dir, f.path), ParseOptions{FolderSeason: true})
+		if info.Season != f.season || info.Episode != f.episode {
+			t.Errorf("ParsePath(%q) season/episode = %q/%q, want %q/%q", f.path, info.Season, info.Episode, f.season, f.episode)
+		}
+	}
+}
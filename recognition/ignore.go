@@ -0,0 +1,74 @@
+package recognition
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName 目录中列出需要排除的文件的忽略文件，每行一个通配符模式，# 开头的行为注释
+const IgnoreFileName = ".crignore"
+
+// ignorePattern 忽略文件中的一条模式
+type ignorePattern struct {
+	glob    string
+	dirOnly bool // 以 / 结尾，只匹配目录
+	path    bool // 包含 /，匹配相对于起始目录的完整路径，否则匹配任意层级的文件名或目录名
+}
+
+// ignoreList 忽略文件中的全部模式
+type ignoreList []ignorePattern
+
+// readIgnoreFile 读取dir中的忽略文件，文件不存在时返回nil；模式语法错误时返回带行号的错误
+func readIgnoreFile(dir string) (ignoreList, error) {
+	ignorePath := filepath.Join(dir, IgnoreFileName)
+	file, err := os.Open(ignorePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var list ignoreList
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		p := ignorePattern{glob: text}
+		if strings.HasSuffix(p.glob, "/") {
+			p.glob, p.dirOnly = strings.TrimRight(p.glob, "/"), true
+		}
+		if strings.Contains(p.glob, "/") {
+			p.glob, p.path = strings.TrimPrefix(p.glob, "/"), true
+		}
+		if _, err := path.Match(p.glob, ""); err != nil || p.glob == "" {
+			return nil, fmt.Errorf("%s 第%d行的模式 %q 无效", ignorePath, line, text)
+		}
+		list = append(list, p)
+	}
+	return list, scanner.Err()
+}
+
+// match 判断相对于起始目录的路径rel（以 / 分隔）是否被忽略
+func (l ignoreList) match(rel string, isDir bool) bool {
+	for _, p := range l {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		name := rel
+		if !p.path {
+			name = rel[strings.LastIndex(rel, "/")+1:]
+		}
+		if ok, _ := path.Match(p.glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
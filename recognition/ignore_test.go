@@ -0,0 +1,46 @@
+package recognition
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindMatchingFilesIgnore(t *testing.T) {
+	files := []string{
+		"Show.S01E01.1080p.mkv",
+		"Show.S01E02.1080p.mkv",
+		"Show.S01E02.1080p.sample.mkv",
+		"Sample/Show.S01E03.1080p.mkv",
+		"Season 1/Show.S01E04.1080p.mkv",
+		"Season 1/extras/Show.S01E05.1080p.mkv",
+		"extras.mkv",
+	}
+	dir := writeFixture(t, files...)
+	ignore := "# 样片和花絮\n*.sample.mkv\n\n  Sample/  \nSeason 1/extras\nextras/\n"
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{})
+	if err != nil {
+		t.Fatalf("FindMatchingFiles() error = %v", err)
+	}
+	want := []string{"Season 1/Show.S01E04.1080p.mkv", "Show.S01E01.1080p.mkv", "Show.S01E02.1080p.mkv", "extras.mkv"}
+	if len(got) != len(want) {
+		t.Fatalf("FindMatchingFiles() = %q, want %q", got, want)
+	}
+	for i, file := range got {
+		if file != fixturePath(dir, want[i]) {
+			t.Errorf("FindMatchingFiles()[%d] = %q, want %q", i, file, want[i])
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("ok.mkv\n[bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{}); err == nil || !strings.Contains(err.Error(), "第2行") {
+		t.Errorf("FindMatchingFiles() with invalid pattern error = %v, want it to name line 2", err)
+	}
+}
@@ -0,0 +1,195 @@
+package recognition
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// VideoFilePattern 匹配常见视频文件扩展名（包括 .mkv.001、.mkv.part2 这类分卷文件），用于未指定标题时匹配目录下所有视频文件
+const VideoFilePattern = `(?i)\.(mkv|mp4|avi|ts|m2ts|rmvb|wmv|mov|flv|webm|iso)(\.(\d{3}|part\d{1,3}))?$`
+
+// knownExtRegex 视频以及随视频一起出现的字幕、元数据文件的扩展名
+var knownExtRegex = regexp.MustCompile(`(?i)^\.(mkv|mp4|avi|ts|m2ts|rmvb|wmv|mov|flv|webm|iso|nfo|srt|ass|ssa|sub|idx|sup|vtt)$`)
+
+// FileExt 返回文件的扩展名（含点号），不是已知的视频、字幕或元数据扩展名时返回空字符串，
+// 避免把没有扩展名的 Show.S01E01、Show.S01E01.x265 中的 .S01E01、.x265 当作扩展名；
+// 分卷文件连同分卷后缀一起作为扩展名，如 .mkv.001，重命名后各分卷仍保留各自的后缀
+func FileExt(name string) string {
+	if suffix, _ := SplitPart(name); suffix != "" {
+		return filepath.Ext(strings.TrimSuffix(name, suffix)) + suffix
+	}
+	if ext := filepath.Ext(name); knownExtRegex.MatchString(ext) {
+		return ext
+	}
+	return ""
+}
+
+// MatchOptions 控制FindMatchingFiles的匹配方式
+type MatchOptions struct {
+	FixedTitle string  // 用于模糊匹配的标题
+	Fuzzy      float64 // 模糊匹配阈值（标准化编辑距离），0表示仅精确匹配
+	MinSize    int64   // 小于该字节数的文件（如未下载完成的文件）不参与匹配，0表示不限制
+	Levels     int     // 最多查找的目录层数，1表示只查找起始目录，2表示再加上其直接子目录，0表示不限制
+}
+
+// Warnings 不为nil时，FindMatchingFiles跳过无法读取的子目录时会在此输出提示
+var Warnings io.Writer
+
+// walkOptions 控制walkFiles的遍历方式
+type walkOptions struct {
+	warn   bool       // 将无权限读取而跳过的路径输出到Warnings
+	ignore ignoreList // 被忽略的文件和目录（连同其中的所有文件）不会传给fn
+	levels int        // 最多遍历的目录层数，含义同MatchOptions.Levels
+}
+
+// tooDeep 判断目录path中的文件是否超出了从dir开始的levels层，层数按相对路径中的分隔符计算
+func tooDeep(dir, path string, levels int) bool {
+	if levels <= 0 || path == dir {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return strings.Count(filepath.ToSlash(rel), "/")+2 > levels
+}
+
+// walkFiles 递归遍历dir下的所有文件，无权限读取的子目录和文件会被跳过而不是中断整个扫描
+func walkFiles(dir string, opts walkOptions, fn func(path string, info os.FileInfo)) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir || !errors.Is(err, fs.ErrPermission) {
+				return err
+			}
+			if opts.warn && Warnings != nil {
+				fmt.Fprintf(Warnings, "无权限读取，已跳过: %s\n", path)
+			}
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() && tooDeep(dir, path, opts.levels) {
+			return filepath.SkipDir
+		}
+		if len(opts.ignore) > 0 && path != dir {
+			if rel, err := filepath.Rel(dir, path); err == nil && opts.ignore.match(filepath.ToSlash(rel), info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !info.IsDir() {
+			fn(path, info)
+		}
+		return nil
+	})
+}
+
+// FindMatchingFiles 递归查找目录中文件名匹配pattern的文件，开启模糊匹配时附带标题相近的文件；
+// 同时返回文件名匹配但因小于opts.MinSize被排除的文件。dir中的忽略文件（IgnoreFileName）列出的文件不参与匹配
+func FindMatchingFiles(dir, pattern string, opts MatchOptions) (files, small []string, err error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	ignore, err := readIgnoreFile(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fuzzyFiles []string
+	err = walkFiles(dir, walkOptions{warn: true, ignore: ignore, levels: opts.Levels}, func(path string, info os.FileInfo) {
+		matched := re.MatchString(info.Name())
+		fuzzy := !matched && opts.Fuzzy > 0 && FuzzyTitleMatch(info.Name(), opts.FixedTitle, opts.Fuzzy)
+		if (matched || fuzzy) && info.Size() < opts.MinSize {
+			small = append(small, path)
+			return
+		}
+		if matched {
+			files = append(files, path)
+		} else if fuzzy {
+			fuzzyFiles = append(fuzzyFiles, path)
+		}
+	})
+	// 精确匹配的文件排在前面，保证以其作为生成规则的基准
+	return append(files, fuzzyFiles...), small, err
+}
+
+// normalizeForFuzzy 统一大小写并去掉常见分隔符，便于模糊比较
+func normalizeForFuzzy(s string) []rune {
+	s = strings.ToLower(s)
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch r {
+		case '.', '_', '-', ' ', '　':
+			continue
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// FuzzyTitleMatch 判断文件名中是否存在与标题足够接近的片段。
+// 计算标题与文件名任意子串之间的最小编辑距离，再按标题长度标准化后与阈值比较。
+func FuzzyTitleMatch(fileName, title string, threshold float64) bool {
+	t := normalizeForFuzzy(title)
+	n := normalizeForFuzzy(fileName)
+	if len(t) == 0 {
+		return false
+	}
+
+	// prev[j]/curr[j]: 标题前i个字符与以文件名第j个字符结尾的子串之间的编辑距离
+	prev := make([]int, len(n)+1)
+	curr := make([]int, len(n)+1)
+	for i := 1; i <= len(t); i++ {
+		curr[0] = i
+		for j := 1; j <= len(n); j++ {
+			cost := 1
+			if t[i-1] == n[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+
+	best := len(t)
+	for _, d := range prev {
+		best = min(best, d)
+	}
+	return float64(best)/float64(len(t)) <= threshold
+}
+
+// FindExtraMatches 返回目录中被批量匹配模式命中、但不在已匹配文件集合中的文件，忽略文件列出的文件除外；
+// levels的含义同MatchOptions.Levels
+func FindExtraMatches(dir, matchPattern string, files []string, levels int) ([]string, error) {
+	re, err := regexp.Compile(matchPattern)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := readIgnoreFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(files))
+	for _, file := range files {
+		known[file] = true
+	}
+
+	var extra []string
+	err = walkFiles(dir, walkOptions{ignore: ignore, levels: levels}, func(path string, info os.FileInfo) {
+		if !known[path] && re.MatchString(info.Name()) {
+			extra = append(extra, path)
+		}
+	})
+	return extra, err
+}
@@ -0,0 +1,108 @@
+package recognition
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindMatchingFilesLevels(t *testing.T) {
+	files := []string{
+		"Show.S01E01.1080p.mkv",
+		"Season 2/Show.S02E01.1080p.mkv",
+		"Season 2/Extras/Show.S02E01.Sample.1080p.mkv",
+		"Movie.2010.1080p/BDMV/STREAM/00000.m2ts",
+		"Discs/Movie.2011.1080p/BDMV/STREAM/00000.m2ts",
+	}
+	dir := writeFixture(t, files...)
+
+	tests := []struct {
+		levels int
+		files  int
+		discs  int
+	}{
+		{0, 5, 2},
+		{1, 1, 0},
+		{2, 2, 1},
+		{3, 3, 2},
+	}
+	for _, tt := range tests {
+		got, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{Levels: tt.levels})
+		if err != nil {
+			t.Fatalf("FindMatchingFiles() error = %v", err)
+		}
+		if len(got) != tt.files {
+			t.Errorf("FindMatchingFiles() with Levels %d = %q, want %d files", tt.levels, got, tt.files)
+		}
+		discs, err := FindDiscs(dir, "", tt.levels)
+		if err != nil {
+			t.Fatalf("FindDiscs() error = %v", err)
+		}
+		if len(discs) != tt.discs {
+			t.Errorf("FindDiscs() with levels %d = %q, want %d discs", tt.levels, discs, tt.discs)
+		}
+		extra, err := FindExtraMatches(dir, VideoFilePattern, nil, tt.levels)
+		if err != nil {
+			t.Fatalf("FindExtraMatches() error = %v", err)
+		}
+		if len(extra) != tt.files {
+			t.Errorf("FindExtraMatches() with levels %d = %q, want %d files", tt.levels, extra, tt.files)
+		}
+	}
+}
+
+func TestFuzzyTitleMatch(t *testing.T) {
+	tests := []struct {
+		fileName  string
+		title     string
+		threshold float64
+		want      bool
+	}{
+		{"breaking.bad.S01E01.mkv", "Breaking Bad", 0, true},        // 大小写
+		{"Breaking_Bad-S01E01.mkv", "Breaking.Bad", 0, true},        // 分隔符
+		{"Breakng.Bad.S01E01.mkv", "Breaking Bad", 0, false},        // 错别字，精确匹配时不命中
+		{"Breakng.Bad.S01E01.mkv", "Breaking Bad", 0.1, true},       // 错别字
+		{"Pokemon.S01E01.mkv", "Pokémon", 0.2, true},                // 重音符号
+		{"Pokemon.S01E01.mkv", "Pokémon", 0, false},                 // 重音符号，精确匹配时不命中
+		{"Office.S01E01.mkv", "The Office", 0.4, true},              // 缺少冠词
+		{"Office.S01E01.mkv", "The Office", 0.3, false},             // 缺少冠词，阈值过低
+		{"诛仙.第2季.第03集.mp4", "誅仙", 0.5, true},                        // 异体字
+		{"诛仙.第2季.第03集.mp4", "誅仙", 0.4, false},                       // 异体字，阈值过低
+		{"Better.Call.Saul.S01E01.mkv", "Breaking Bad", 0.2, false}, // 不同的标题
+		{"Show.S01E01.mkv", "", 1, false},                           // 空标题
+	}
+	for _, tt := range tests {
+		if got := FuzzyTitleMatch(tt.fileName, tt.title, tt.threshold); got != tt.want {
+			t.Errorf("FuzzyTitleMatch(%q, %q, %v) = %v, want %v", tt.fileName, tt.title, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestFindMatchingFilesPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root 用户不受目录权限限制")
+	}
+	dir := writeFixture(t, "Show.S01E01.mkv", "Locked/Show.S01E02.mkv", "Open/Show.S01E03.mkv")
+	locked := fixturePath(dir, "Locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	var warnings strings.Builder
+	Warnings = &warnings
+	t.Cleanup(func() { Warnings = nil })
+
+	got, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{})
+	if err != nil {
+		t.Fatalf("FindMatchingFiles() error = %v, want the locked directory to be skipped", err)
+	}
+	want := []string{fixturePath(dir, "Open/Show.S01E03.mkv"), fixturePath(dir, "Show.S01E01.mkv")}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("FindMatchingFiles() = %q, want %q", got, want)
+	}
+	if !strings.Contains(warnings.String(), filepath.Base(locked)) {
+		t.Errorf("Warnings = %q, want the skipped directory", warnings.String())
+	}
+}
@@ -0,0 +1,91 @@
+package recognition
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Matcher 从文件名中识别季集等信息的策略，可通过RegisterMatcher注册自定义的实现
+type Matcher interface {
+	// Match 解析文件名（不含目录），返回识别结果和0到1之间的置信度，没有识别出集数时置信度为0
+	Match(fileName string, opts ParseOptions) (FileInfo, float64)
+}
+
+// MatcherFunc 将普通函数适配为Matcher
+type MatcherFunc func(fileName string, opts ParseOptions) (FileInfo, float64)
+
+func (f MatcherFunc) Match(fileName string, opts ParseOptions) (FileInfo, float64) {
+	return f(fileName, opts)
+}
+
+// DefaultMatcher 默认的正则识别策略，按优先级依次尝试季集规则和仅集数规则
+var DefaultMatcher Matcher = MatcherFunc(regexMatch)
+
+// regexMatch 季集规则识别出的结果置信度最高，多个规则结果冲突时降低，仅集数规则（季数为默认值）最低
+func regexMatch(fileName string, opts ParseOptions) (FileInfo, float64) {
+	info := parseFileName(fileName, opts)
+	switch {
+	case info.Episode == "":
+		return info, 0
+	case info.DefaultSeason:
+		return info, 0.5
+	case info.Warning != "":
+		return info, 0.7
+	}
+	return info, 1
+}
+
+// animeEpisodeRegex 动画发布常见的 [Group] Show - 05 [1080p]、Show - 12v2 格式中的集数
+var animeEpisodeRegex = regexp.MustCompile(`\s-\s(\d{1,4})(?:v\d)?(?:[\s.\[(]|$)`)
+
+// animeMatch 在默认规则的基础上识别 Show - 05 这类动画常用的集数格式，默认规则没有识别出集数时使用
+func animeMatch(fileName string, opts ParseOptions) (FileInfo, float64) {
+	info, confidence := regexMatch(fileName, opts)
+	if info.Episode != "" {
+		return info, confidence
+	}
+	name := normalizeName(fileName, opts)
+	matches := animeEpisodeRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return info, 0
+	}
+	info.Episode = normalizeNumber(matches[1])
+	info.FullMatch = matches[0]
+	info.EpisodeTitle = episodeTitle(name, info.FullMatch)
+	if info.Season == "" {
+		info.Season = "01"
+		info.DefaultSeason = true
+	}
+	return info, 0.6
+}
+
+var matchers = map[string]Matcher{
+	"regex": DefaultMatcher,
+	"anime": MatcherFunc(animeMatch),
+}
+
+// RegisterMatcher 注册一个识别策略，名称已存在时返回错误
+func RegisterMatcher(name string, m Matcher) error {
+	if _, ok := matchers[name]; ok {
+		return fmt.Errorf("识别策略 %s 已存在", name)
+	}
+	matchers[name] = m
+	return nil
+}
+
+// LookupMatcher 按名称查找识别策略
+func LookupMatcher(name string) (Matcher, bool) {
+	m, ok := matchers[name]
+	return m, ok
+}
+
+// MatcherNames 返回所有已注册的识别策略名称，按字母顺序排列
+func MatcherNames() []string {
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
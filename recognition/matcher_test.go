@@ -0,0 +1,53 @@
+package recognition
+
+import "testing"
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		matcher    string
+		name       string
+		episode    string
+		confidence float64
+	}{
+		{"regex", "Show.S01E05.1080p.mkv", "05", 1},
+		{"regex", "Show.E05.1080p.mkv", "05", 0.5},
+		{"regex", "[SubsPlease] Show - 05 (1080p).mkv", "", 0},
+		{"anime", "[SubsPlease] Show - 05 (1080p).mkv", "05", 0.6},
+		{"anime", "[Grp] Show - 12v2 [1080p].mkv", "12", 0.6},
+		{"anime", "[Grp] Show 2 - 103 [720p].mkv", "103", 0.6},
+		{"anime", "Show.S02E03.1080p.mkv", "03", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.matcher+"/"+tt.name, func(t *testing.T) {
+			m, ok := LookupMatcher(tt.matcher)
+			if !ok {
+				t.Fatalf("LookupMatcher(%q) not found", tt.matcher)
+			}
+			info, confidence := m.Match(tt.name, ParseOptions{})
+			if info.Episode != tt.episode || confidence != tt.confidence {
+				t.Errorf("Match() = episode %q, confidence %v, want %q, %v", info.Episode, confidence, tt.episode, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	defer delete(matchers, "fixed")
+
+	fixed := MatcherFunc(func(fileName string, opts ParseOptions) (FileInfo, float64) {
+		return FileInfo{Season: "03", Episode: "07"}, 0.9
+	})
+	if err := RegisterMatcher("fixed", fixed); err != nil {
+		t.Fatalf("RegisterMatcher() error = %v", err)
+	}
+	if err := RegisterMatcher("fixed", fixed); err == nil {
+		t.Error("RegisterMatcher() with a duplicate name error = nil")
+	}
+
+	m, _ := LookupMatcher("fixed")
+	info, confidence := MatchPath("any/file.mkv", ParseOptions{Matcher: m})
+	if info.Season != "03" || info.Episode != "07" || confidence != 0.9 {
+		t.Errorf("MatchPath() = %+v, %v", info, confidence)
+	}
+}
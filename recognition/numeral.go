@@ -0,0 +1,37 @@
+package recognition
+
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+var chineseUnits = map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+// ParseChineseNumber 将中文数字（如 十二、二十三、一百、一百零五）转换为整数
+func ParseChineseNumber(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	total, digit := 0, -1
+	for _, r := range s {
+		if d, ok := chineseDigits[r]; ok {
+			digit = d
+			continue
+		}
+		unit, ok := chineseUnits[r]
+		if !ok {
+			return 0, false
+		}
+		// “十二”中的“十”前面没有数字，视为一十
+		if digit == -1 {
+			digit = 1
+		}
+		total += digit * unit
+		digit = -1
+	}
+	if digit > 0 {
+		total += digit
+	}
+	return total, true
+}
@@ -0,0 +1,595 @@
+// Package recognition 从影视文件名中识别季集、视频格式等信息，并生成正则替换规则。
+package recognition
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileInfo 从文件名中解析出的信息
+type FileInfo struct {
+	FullMatch   string
+	Season      string
+	Episode     string
+	VideoFormat string
+	BitDepth    string // 色深，如 10bit
+	Codec       string // 视频编码，如 x265、HEVC、AV1
+	Volume      string // 卷号，如 Vol.1、Volume 2，两位数字
+	Source      string // 片源，如 WEB-DL、BluRay、Remux
+	Audio       string // 音频，保持文件名中的写法，如 DDP5.1、TrueHD
+	Group       string // 发布组，如 -ADWeb 结尾或 [Group] 开头
+	Flags       string // 多音轨、多字幕标记，保持文件名中的写法，多个时以 . 连接，如 DUAL.繁中
+	Part        string // 分段文件的序号标记，如 CD1、Disc2，同一部电影分为多个文件时出现
+	Year        string // 文件名中的年份，如 Movie.2010.1080p 中的 2010，有多个时取最后一个（标题本身可能是年份，如 2012.2009）
+
+	EpisodeTitle string // 季集标记与画质等标记之间的单集标题，如 Show.S01E01.Pilot.1080p 中的 Pilot
+
+	DefaultSeason bool   // 文件名中没有季数信息，Season为默认的01
+	Warning       string // 多个季集规则识别出不同的结果时的提示
+}
+
+// ParseOptions 控制ParsePath的解析行为
+type ParseOptions struct {
+	Matcher Matcher // 识别季集的策略，为nil时使用DefaultMatcher
+
+	FolderSeason        bool // 文件名中没有季数时，从所在文件夹名称（如 Season 3、S03、第三季）中读取季数
+	KeepResolutionLabel bool // 保留 4K/8K 等标签，不统一为 2160P/4320P
+	VolumeAsSeason      bool // 文件名中没有季数时，将卷号（Vol.2、Volume 2）作为季数
+	PercentDecode       bool // 解析前先解码 %20、%5B 等URL编码，无法解码时保持原样
+
+	// EpisodeOffset 从识别出的集数中减去的偏移量，用于跨季连续编号（如第二季为13-24集）的文件
+	EpisodeOffset int
+
+	// EpisodeMap 将识别出的季集（减去EpisodeOffset之后）映射为其他排序中的季集，如按TMDB剧集组映射为默认排序；
+	// 为nil时不映射，返回错误时保留原季集并在Warning中给出提示
+	EpisodeMap func(season, episode int) (int, int, error)
+
+	// Title 文件名中的标题，多个季集规则识别出不同的结果时优先采用离标题最近的一个，为空时以文件名开头为准
+	Title string
+}
+
+// EnsureTwoDigits 将一位数补齐为两位
+func EnsureTwoDigits(num string) string {
+	if len(num) == 1 {
+		return "0" + num
+	}
+	return num
+}
+
+// 识别用的正则表达式，在包初始化时编译一次；同一类中越靠前的优先级越高
+var (
+	// 视频格式只要求前面是分隔符或开头，后面的边界在formatTokens中单独判断，
+	// 这样 2160p.HDR.DV 这类仅以点号相连的标记可以逐个识别，HDR10+ 结尾的+也不会影响边界
+	formatRegex   = regexp.MustCompile(`(?:^|[^A-Za-z0-9])(1080[pP]|720[pP]|2160[pP]|4[kK]|8[kK]|480[pP]|HDR10\+|HDR10|HDR|DV|DoVi|HEVC|H265)`)
+	bitDepthRegex = regexp.MustCompile(`(?i)\b(8|10|12)[-\s]?bits?\b`)
+	sourceRegex   = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(WEB-?DL|WEB-?Rip|Blu-?Ray|BDRip|BR-?Rip|BDRemux|Remux|HDTV|DVDRip|HDRip)`)
+	audioRegex    = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(DDP\s?[257]\.[01]|DDP|DD\+|E-?AC-?3|AC-?3|AAC\s?[257]\.[01]|AAC|DTS-HD[ .]MA|DTS-HD|DTS-?X|DTS|TrueHD|Atmos|FLAC|Opus)`)
+	// 多音轨、多字幕标记，英文标记前后需为分隔符，单独的 DUAL、MULTi 区分大小写，避免误识别 Dual.Survival 这类标题；中文标记直接匹配
+	flagsRegex        = regexp.MustCompile(`(?:^|[^A-Za-z0-9])((?i:Dual[-.]?Audio|Multi[-.]?(?:Subs?|Audio))|DUAL|MULTi|MULTI|\d+Audios?)`)
+	chineseFlagsRegex = regexp.MustCompile(`国粤双语|国英双语|中英双语|双语|简繁中字|简繁双字|简繁|简中|繁中|中字|双字幕|内封字幕|多音轨`)
+	// 发布组：文件名开头的 [Group]，或结尾（扩展名之前）的 -Group
+	groupRegex  = regexp.MustCompile(`^\[([^\]]+)\]|-([A-Za-z0-9]+)(?:\.[A-Za-z0-9]{2,4})?$`)
+	volumeRegex = regexp.MustCompile(`(?i)\bvol(?:ume)?[\s._-]*(\d{1,2})\b`)
+	codecRegex  = regexp.MustCompile(`(?i)\b(x26[45]|h\.?26[45]|HEVC|AVC|AV1)\b`)
+	// 分段标记：CD1、CD 2、Disc1、Disk.2，前后需为分隔符
+	partRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(CD|Dis[ck])[\s._-]*(\d{1,2})(?:[^a-z0-9]|$)`)
+)
+
+var seasonEpisodePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[Ss](\d{1,2})[Ee](\d{1,3})`), // 长篇剧集可能有 S01E100 这类三位数的集数
+	regexp.MustCompile(`第(\d{1,2})季.?第(\d{1,2})集`),
+	regexp.MustCompile(`第([零〇一二两三四五六七八九十]+)季.?第([零〇一二两三四五六七八九十百]+)集`),
+	regexp.MustCompile(`Season\s*(\d{1,2}).*?Episode\s*(\d{1,2})`),
+	regexp.MustCompile(`(?:^|[^0-9])(\d{1,2})[xX](\d{1,3})(?:[^0-9]|$)`), // 1x05，前后不能紧跟数字，避免误识别 1920x1080
+}
+
+var episodeOnlyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[Ee](\d{1,2})[^0-9]`),
+	regexp.MustCompile(`第(\d{1,2})集`),
+	regexp.MustCompile(`第([零〇一二两三四五六七八九十百]+)集`),
+	regexp.MustCompile(`[Ee]p\.?(\d{1,2})`),
+	regexp.MustCompile(`[Ee]pisode\.?(\d{1,2})`),
+	regexp.MustCompile(`EP(\d{1,2})`),
+	regexp.MustCompile(`Ep(\d{1,2})`),
+	bareEpisodeRegex,
+}
+
+// bareEpisodeRegex 仅以集数命名的文件，如 01.mkv、05 - Title.mkv；文件名中带有年份时不使用，
+// 避免把 300.Rise.of.an.Empire.2014 这类以数字开头的电影标题识别为集数
+var bareEpisodeRegex = regexp.MustCompile(`^(\d{1,3})(?:[\s._\-]|$)`)
+
+// AddPatterns 将自定义识别规则追加到内置规则之后，返回无法编译或分组数量不正确的规则对应的错误
+func AddPatterns(seasonEpisode, episodeOnly []string) []error {
+	var errs []error
+	add := func(list *[]*regexp.Regexp, patterns []string, groups int, kind string) {
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s规则 %q 无法编译: %v", kind, pattern, err))
+				continue
+			}
+			if re.NumSubexp() != groups {
+				errs = append(errs, fmt.Errorf("%s规则 %q 需要%d个分组，实际为%d个", kind, pattern, groups, re.NumSubexp()))
+				continue
+			}
+			*list = append(*list, re)
+		}
+	}
+	add(&seasonEpisodePatterns, seasonEpisode, 2, "季集")
+	add(&episodeOnlyPatterns, episodeOnly, 1, "仅集数")
+	return errs
+}
+
+// resolutionAliases 分辨率标签对应的标准写法
+var resolutionAliases = map[string]string{
+	"4K": "2160P",
+	"8K": "4320P",
+}
+
+// dimensionRegex 以“宽x高”表示的分辨率，如 1920x1080、3840×2160；宽高都是3到4位数字，
+// 不会与 1x05 这类季集标记混淆，只有dimensionFormats中的组合才会被识别
+var dimensionRegex = regexp.MustCompile(`(?:^|[^0-9])(\d{3,4})[xX×](\d{3,4})(?:[^0-9]|$)`)
+
+// dimensionFormats 常见的宽高组合对应的分辨率标记
+var dimensionFormats = map[string]string{
+	"640x480":   "480P",
+	"720x480":   "480P",
+	"854x480":   "480P",
+	"1280x720":  "720P",
+	"1440x1080": "1080P",
+	"1920x1080": "1080P",
+	"3840x2160": "2160P",
+	"4096x2160": "2160P",
+	"7680x4320": "4320P",
+}
+
+// dimensionFormat 返回文件名中第一个常见宽高组合对应的分辨率标记，没有时返回空字符串
+func dimensionFormat(fileName string) string {
+	for _, m := range dimensionRegex.FindAllStringSubmatch(fileName, -1) {
+		if format, ok := dimensionFormats[m[1]+"x"+m[2]]; ok {
+			return format
+		}
+	}
+	return ""
+}
+
+// ParseFileName 从文件名中解析季集、视频格式和色深，4K/8K 统一为 2160P/4320P
+func ParseFileName(fileName string) FileInfo {
+	return parseFileName(fileName, ParseOptions{})
+}
+
+// normalizeName 按选项解码URL编码并统一全角字符，去掉分卷后缀，得到实际用于识别的文件名
+func normalizeName(fileName string, opts ParseOptions) string {
+	fileName = SplitBase(fileName)
+	if opts.PercentDecode {
+		if decoded, err := url.PathUnescape(fileName); err == nil {
+			fileName = decoded
+		}
+	}
+	return NormalizeWidth(fileName)
+}
+
+func parseFileName(fileName string, opts ParseOptions) FileInfo {
+	info := FileInfo{}
+	fileName = normalizeName(fileName, opts)
+
+	if matches := formatTokens(fileName); len(matches) > 0 {
+		formats := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, match := range matches {
+			format := strings.ToUpper(match)
+			if format == "DOVI" {
+				format = "DV"
+			}
+			if format == "HEVC" || format == "H265" {
+				continue // 跳过编码格式
+			}
+			if alias, ok := resolutionAliases[format]; ok && !opts.KeepResolutionLabel {
+				format = alias
+			}
+			if seen[format] {
+				continue // 如 4K.2160p 统一后重复
+			}
+			seen[format] = true
+			formats = append(formats, format)
+		}
+		info.VideoFormat = strings.Join(formats, ".")
+	}
+	// 没有 1080p 这类分辨率标记时，使用 1920x1080 这类宽高换算出的分辨率，放在其他格式标记之前
+	if format := dimensionFormat(fileName); format != "" && resolution(info.VideoFormat) == 0 {
+		info.VideoFormat = strings.TrimSuffix(format+"."+info.VideoFormat, ".")
+	}
+
+	if matches := bitDepthRegex.FindStringSubmatch(fileName); len(matches) == 2 {
+		info.BitDepth = matches[1] + "bit"
+	}
+
+	if matches := codecRegex.FindStringSubmatch(fileName); len(matches) == 2 {
+		info.Codec = normalizeCodec(matches[1])
+	}
+
+	// BluRay.REMUX 这类同时带有两个片源标记的文件按 Remux 处理，其余取第一个
+	for _, token := range boundedTokens(sourceRegex, fileName) {
+		if source := normalizeSource(token); info.Source == "" || source == "Remux" {
+			info.Source = source
+		}
+	}
+	if tokens := boundedTokens(audioRegex, fileName); len(tokens) > 0 {
+		info.Audio = tokens[0]
+	}
+	flags := boundedTokens(flagsRegex, fileName)
+	flags = append(flags, chineseFlagsRegex.FindAllString(fileName, -1)...)
+	info.Flags = strings.Join(flags, ".")
+
+	// 只有带视频格式或片源标记的发布文件名才识别结尾的 -Group，避免把 Spider-Man 识别为发布组；
+	// WEB-DL、Blu-Ray、DTS-HD 这类片源、音频标记结尾的 -DL、-Ray 不是发布组
+	if loc := groupRegex.FindStringSubmatchIndex(fileName); loc != nil {
+		if loc[2] >= 0 {
+			info.Group = fileName[loc[2]:loc[3]]
+		} else if (info.VideoFormat != "" || info.Source != "") && !insideToken(fileName, loc[4], loc[5]) {
+			info.Group = fileName[loc[4]:loc[5]]
+		}
+	}
+
+	if matches := volumeRegex.FindStringSubmatch(fileName); len(matches) == 2 {
+		info.Volume = normalizeNumber(matches[1])
+	}
+
+	if matches := partRegex.FindStringSubmatch(fileName); len(matches) == 3 {
+		label := "CD"
+		if !strings.EqualFold(matches[1], "CD") {
+			label = "Disc"
+		}
+		n, _ := strconv.Atoi(matches[2])
+		info.Part = label + strconv.Itoa(n)
+	}
+
+	if years := boundedTokens(fileYearRegex, fileName); len(years) > 0 {
+		info.Year = years[len(years)-1]
+	}
+
+	// 季集规则总是优先于仅集数规则；只有季集规则都没有匹配时才尝试仅集数规则
+	foundMatch := false
+	if m, warning, ok := matchSeasonEpisode(fileName, opts.Title); ok {
+		info.Season = m.season
+		info.Episode = m.episode
+		info.FullMatch = m.full
+		info.Warning = warning
+		foundMatch = true
+	}
+
+	if !foundMatch {
+		for _, re := range episodeOnlyPatterns {
+			if re == bareEpisodeRegex && info.Year != "" {
+				continue
+			}
+			if matches := re.FindStringSubmatch(fileName); len(matches) == 2 {
+				info.Season = "01" // 默认为第一季
+				info.DefaultSeason = true
+				info.Episode = normalizeNumber(matches[1])
+				info.FullMatch = matches[0]
+				break
+			}
+		}
+	}
+
+	if info.FullMatch != "" {
+		info.EpisodeTitle = episodeTitle(fileName, info.FullMatch)
+	}
+
+	if opts.VolumeAsSeason && info.Volume != "" && (info.Season == "" || info.DefaultSeason) {
+		info.Season = info.Volume
+		info.DefaultSeason = false
+	}
+
+	return info
+}
+
+var (
+	// episodeTitleStops 单集标题之后可能出现的标记，单集标题截止到其中最早出现的一个
+	episodeTitleStops = []*regexp.Regexp{formatRegex, bitDepthRegex, codecRegex, sourceRegex, audioRegex,
+		flagsRegex, chineseFlagsRegex, partRegex, revisionRegex, dimensionRegex}
+	yearOnlyRegex = regexp.MustCompile(`^(?:19|20)\d{2}$`)
+	// fileYearRegex 文件名中前后都是分隔符的年份，后面的边界在boundedTokens中判断，不会误识别 1920x1080 中的 1920
+	fileYearRegex = regexp.MustCompile(`(?:^|[^0-9A-Za-z])((?:19|20)\d{2})`)
+)
+
+// episodeTitle 返回文件名中紧跟在季集标记之后、画质等标记之前的文本，只有年份时返回空字符串
+func episodeTitle(fileName, fullMatch string) string {
+	// 扩展名不算作单集标题；季集标记可能连同后面的点号一起延伸到扩展名中，如 01.mkv
+	name := strings.TrimSuffix(fileName, FileExt(fileName))
+	start := strings.Index(name, fullMatch)
+	if start == -1 || start+len(fullMatch) > len(name) {
+		return ""
+	}
+	rest := name[start+len(fullMatch):]
+	end := len(rest)
+	for _, re := range episodeTitleStops {
+		if loc := re.FindStringIndex(rest); loc != nil && loc[0] < end {
+			end = loc[0]
+		}
+	}
+	title := strings.Trim(rest[:end], " ._-[]()")
+	if yearOnlyRegex.MatchString(title) {
+		return ""
+	}
+	return title
+}
+
+type seasonEpisodeMatch struct {
+	season, episode, full string
+	pos                   int
+}
+
+// matchSeasonEpisode 用所有季集规则匹配文件名；结果一致时采用优先级最高的规则，
+// 不一致时采用离标题（没有标题时为文件名开头）最近的一个，并返回提示
+func matchSeasonEpisode(fileName, title string) (seasonEpisodeMatch, string, bool) {
+	var found []seasonEpisodeMatch
+	for _, re := range seasonEpisodePatterns {
+		loc := re.FindStringSubmatchIndex(fileName)
+		if len(loc) != 6 {
+			continue
+		}
+		found = append(found, seasonEpisodeMatch{
+			season:  normalizeNumber(fileName[loc[2]:loc[3]]),
+			episode: normalizeNumber(fileName[loc[4]:loc[5]]),
+			full:    fileName[loc[0]:loc[1]],
+			pos:     loc[0],
+		})
+	}
+	if len(found) == 0 {
+		return seasonEpisodeMatch{}, "", false
+	}
+
+	conflict := false
+	for _, m := range found[1:] {
+		if m.season != found[0].season || m.episode != found[0].episode {
+			conflict = true
+			break
+		}
+	}
+	if !conflict {
+		return found[0], "", true
+	}
+
+	anchor := 0
+	if title != "" {
+		// 全角标题转换后字节数会变少，锚点按转换后的长度计算
+		title = NormalizeWidth(title)
+		if idx := strings.Index(strings.ToLower(fileName), strings.ToLower(title)); idx != -1 {
+			anchor = idx + len(title)
+		}
+	}
+	distance := func(m seasonEpisodeMatch) int {
+		if m.pos < anchor {
+			return anchor - m.pos
+		}
+		return m.pos - anchor
+	}
+
+	best := found[0]
+	candidates := make([]string, 0, len(found))
+	for _, m := range found {
+		candidates = append(candidates, fmt.Sprintf("%q(S%sE%s)", m.full, m.season, m.episode))
+		if distance(m) < distance(best) {
+			best = m
+		}
+	}
+	warning := fmt.Sprintf("识别出多个不同的季集: %s，采用离标题最近的 S%sE%s", strings.Join(candidates, "、"), best.season, best.episode)
+	return best, warning, true
+}
+
+// formatTokens 返回文件名中所有前后都是分隔符（或开头、结尾）的视频格式标记
+func formatTokens(fileName string) []string {
+	return boundedTokens(formatRegex, fileName)
+}
+
+// boundedTokens 返回re第1个分组的所有匹配中，后面是分隔符或结尾的部分；re自身只需限定前面的边界
+func boundedTokens(re *regexp.Regexp, fileName string) []string {
+	var tokens []string
+	for _, loc := range re.FindAllStringSubmatchIndex(fileName, -1) {
+		end := loc[3]
+		if end < len(fileName) && isAlnum(fileName[end]) {
+			continue // 如 1080pX、HDRip
+		}
+		tokens = append(tokens, fileName[loc[2]:end])
+	}
+	return tokens
+}
+
+// insideToken 判断fileName[start:end]是否为某个片源或音频标记（如 WEB-DL 中的 DL）的一部分
+func insideToken(fileName string, start, end int) bool {
+	for _, re := range []*regexp.Regexp{sourceRegex, audioRegex} {
+		for _, loc := range re.FindAllStringSubmatchIndex(fileName, -1) {
+			if loc[2] < start && loc[3] >= end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// sourceNames 片源的标准写法，键为去掉连字符后的小写形式
+var sourceNames = map[string]string{
+	"webdl":   "WEB-DL",
+	"webrip":  "WEBRip",
+	"bluray":  "BluRay",
+	"bdrip":   "BDRip",
+	"brrip":   "BRRip",
+	"bdremux": "Remux",
+	"remux":   "Remux",
+	"hdtv":    "HDTV",
+	"dvdrip":  "DVDRip",
+	"hdrip":   "HDRip",
+}
+
+func normalizeSource(source string) string {
+	if name, ok := sourceNames[strings.ToLower(strings.ReplaceAll(source, "-", ""))]; ok {
+		return name
+	}
+	return source
+}
+
+// normalizeCodec 统一编码的写法：x264/x265保持小写，其余去掉点号并转为大写（h.265 -> H265）
+func normalizeCodec(codec string) string {
+	if codec[0] == 'x' || codec[0] == 'X' {
+		return strings.ToLower(codec)
+	}
+	return strings.ToUpper(strings.ReplaceAll(codec, ".", ""))
+}
+
+// normalizeNumber 将捕获到的阿拉伯数字或中文数字统一为至少两位的阿拉伯数字
+func normalizeNumber(s string) string {
+	if n, ok := ParseChineseNumber(s); ok {
+		return EnsureTwoDigits(strconv.Itoa(n))
+	}
+	return EnsureTwoDigits(s)
+}
+
+// ParsePath 解析文件路径，在ParseFileName的基础上按opts结合所在文件夹的信息
+func ParsePath(path string, opts ParseOptions) FileInfo {
+	info, _ := MatchPath(path, opts)
+	return info
+}
+
+// MatchPath 与ParsePath相同，同时返回opts.Matcher给出的置信度
+func MatchPath(path string, opts ParseOptions) (FileInfo, float64) {
+	matcher := opts.Matcher
+	if matcher == nil {
+		matcher = DefaultMatcher
+	}
+	info, confidence := matcher.Match(filepath.Base(path), opts)
+	if info.DefaultSeason && opts.FolderSeason {
+		if season, ok := ParseSeasonFromDir(filepath.Base(filepath.Dir(path))); ok {
+			info.Season = season
+			info.DefaultSeason = false
+		}
+	}
+	if opts.EpisodeOffset != 0 && info.Episode != "" {
+		applyEpisodeOffset(&info, opts.EpisodeOffset)
+	}
+	if opts.EpisodeMap != nil && info.Episode != "" {
+		applyEpisodeMap(&info, opts.EpisodeMap)
+	}
+	return info, confidence
+}
+
+// applyEpisodeMap 按episodeMap替换季集，保持集数原有的补零位数；映射失败时保留原季集并给出提示
+func applyEpisodeMap(info *FileInfo, episodeMap func(season, episode int) (int, int, error)) {
+	season, _ := strconv.Atoi(info.Season)
+	episode, err := strconv.Atoi(info.Episode)
+	if err != nil {
+		return
+	}
+	mappedSeason, mappedEpisode, err := episodeMap(season, episode)
+	if err != nil {
+		if info.Warning != "" {
+			info.Warning += "；"
+		}
+		info.Warning += fmt.Sprintf("季集映射失败，保留原季集：%v", err)
+		return
+	}
+	info.Season = fmt.Sprintf("%02d", mappedSeason)
+	info.Episode = fmt.Sprintf("%0*d", len(info.Episode), mappedEpisode)
+	info.DefaultSeason = false
+}
+
+// applyEpisodeOffset 从集数中减去偏移量，保持原有的补零位数；结果小于1时保留原集数并给出提示
+func applyEpisodeOffset(info *FileInfo, offset int) {
+	episode, err := strconv.Atoi(info.Episode)
+	if err != nil {
+		return
+	}
+	if episode-offset < 1 {
+		if info.Warning != "" {
+			info.Warning += "；"
+		}
+		info.Warning += fmt.Sprintf("第%d集减去集数偏移量%d后小于1，保留原集数", episode, offset)
+		return
+	}
+	info.Episode = fmt.Sprintf("%0*d", len(info.Episode), episode-offset)
+}
+
+var folderSeasonPatterns = []*regexp.Regexp{
+	// 整季打包的文件夹，如 Show.S01.Complete.1080p、Show Season 1 Complete
+	regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:s|season[\s._-]*)(\d{1,2})[\s._-]*complete\b`),
+	regexp.MustCompile(`(?i)\bseason[\s._-]*(\d{1,2})\b`),
+	regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,2})(?:[^0-9e]|$)`),
+	regexp.MustCompile(`第(\d{1,2})季`),
+	regexp.MustCompile(`第([零〇一二两三四五六七八九十百]+)季`),
+}
+
+// ParseSeasonFromDir 从文件夹名称中解析季数，返回两位数字
+func ParseSeasonFromDir(dirName string) (string, bool) {
+	dirName = NormalizeWidth(dirName)
+	for _, re := range folderSeasonPatterns {
+		matches := re.FindStringSubmatch(dirName)
+		if len(matches) != 2 {
+			continue
+		}
+		if n, err := strconv.Atoi(matches[1]); err == nil {
+			return EnsureTwoDigits(strconv.Itoa(n)), true
+		}
+		if n, ok := ParseChineseNumber(matches[1]); ok {
+			return EnsureTwoDigits(strconv.Itoa(n)), true
+		}
+	}
+	return "", false
+}
+
+// NormalizeWidth 将全角数字、字母和标点（如 ０１、Ｓ、（）、【】）转换为半角，便于正则识别
+func NormalizeWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			return r - 0xFEE0
+		case r == '　':
+			return ' '
+		case r == '【':
+			return '['
+		case r == '】':
+			return ']'
+		}
+		return r
+	}, s)
+}
+
+// PatternGroup 一类识别规则
+type PatternGroup struct {
+	Name     string
+	Patterns []string
+}
+
+// PatternGroups 按优先级返回所有识别用的正则表达式，用于排查文件名为何没有被识别
+func PatternGroups() []PatternGroup {
+	return []PatternGroup{
+		{Name: "季集", Patterns: patternStrings(seasonEpisodePatterns)},
+		{Name: "仅集数（季数默认为01）", Patterns: patternStrings(episodeOnlyPatterns)},
+		{Name: "文件夹季数", Patterns: patternStrings(folderSeasonPatterns)},
+		{Name: "视频格式", Patterns: patternStrings([]*regexp.Regexp{formatRegex, dimensionRegex})},
+		{Name: "色深", Patterns: patternStrings([]*regexp.Regexp{bitDepthRegex})},
+		{Name: "编码", Patterns: patternStrings([]*regexp.Regexp{codecRegex})},
+		{Name: "卷号", Patterns: patternStrings([]*regexp.Regexp{volumeRegex})},
+		{Name: "分段", Patterns: patternStrings([]*regexp.Regexp{partRegex})},
+		{Name: "片源", Patterns: patternStrings([]*regexp.Regexp{sourceRegex})},
+		{Name: "音频", Patterns: patternStrings([]*regexp.Regexp{audioRegex})},
+		{Name: "发布组", Patterns: patternStrings([]*regexp.Regexp{groupRegex})},
+		{Name: "多音轨/多字幕", Patterns: patternStrings([]*regexp.Regexp{flagsRegex, chineseFlagsRegex})},
+	}
+}
+
+func patternStrings(res []*regexp.Regexp) []string {
+	patterns := make([]string, 0, len(res))
+	for _, re := range res {
+		patterns = append(patterns, re.String())
+	}
+	return patterns
+}
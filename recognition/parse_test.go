@@ -0,0 +1,701 @@
+package recognition
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileNameBitDepth(t *testing.T) {
+	tests := []struct {
+		name        string
+		season      string
+		episode     string
+		videoFormat string
+		bitDepth    string
+	}{
+		{"Show.S01E01.1080p.10bit.HEVC.mkv", "01", "01", "1080P", "10bit"},
+		{"Show.S02E03.2160p.HDR.10-bit.x265.mkv", "02", "03", "2160P.HDR", "10bit"},
+		{"Show.S01E05.720p.8bit.mkv", "01", "05", "720P", "8bit"},
+		{"Show.S01E06.1080p.WEB-DL.mkv", "01", "06", "1080P", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+			if info.VideoFormat != tt.videoFormat {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.videoFormat)
+			}
+			if info.BitDepth != tt.bitDepth {
+				t.Errorf("BitDepth = %q, want %q", info.BitDepth, tt.bitDepth)
+			}
+		})
+	}
+}
+
+func TestParseFileNameCodec(t *testing.T) {
+	tests := []struct {
+		name        string
+		videoFormat string
+		codec       string
+	}{
+		{"Show.S01E01.1080p.WEB-DL.x265-GROUP.mkv", "1080P", "x265"},
+		{"Show.S01E02.2160p.AV1.Opus.mkv", "2160P", "AV1"},
+		{"Show.S01E03.1080p.HEVC.10bit.mkv", "1080P", "HEVC"},
+		{"Show.S01E04.720p.H.264.AAC.mkv", "720P", "H264"},
+		{"Show.S01E05.1080p.WEB-DL.mkv", "1080P", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.VideoFormat != tt.videoFormat {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.videoFormat)
+			}
+			if info.Codec != tt.codec {
+				t.Errorf("Codec = %q, want %q", info.Codec, tt.codec)
+			}
+		})
+	}
+}
+
+func TestParseFileNameXSeparator(t *testing.T) {
+	tests := []struct {
+		name    string
+		season  string
+		episode string
+	}{
+		{"Show.1x05.mkv", "01", "05"},
+		{"Show - 12x101 - Title.avi", "12", "101"},
+		{"Show.1920x1080.E03.mkv", "01", "03"},
+		{"Show.S02E04.1920x1080.mkv", "02", "04"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+		})
+	}
+}
+
+func TestParseFileNameResolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepLabel bool
+		want      string
+	}{
+		{"Show.S01E01.4K.HDR.mkv", false, "2160P.HDR"},
+		{"Show.S01E01.4k.2160p.mkv", false, "2160P"},
+		{"Show.S01E01.8K.mkv", false, "4320P"},
+		{"Show.S01E01.1080p.mkv", false, "1080P"},
+		{"Show.S01E01.4K.HDR.mkv", true, "4K.HDR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParsePath(tt.name, ParseOptions{KeepResolutionLabel: tt.keepLabel})
+			if info.VideoFormat != tt.want {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFileNameDimensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		season  string
+		episode string
+		format  string
+	}{
+		{"Show.S02E04.1920x1080.mkv", "02", "04", "1080P"},
+		{"Show.1x05.1280x720.HDR.mkv", "01", "05", "720P.HDR"},
+		{"Show.E03.3840×2160.mkv", "01", "03", "2160P"},
+		{"Show.S01E01.1920x1080.2160p.mkv", "01", "01", "2160P"},
+		{"Show.S01E01.1234x567.mkv", "01", "01", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode || info.VideoFormat != tt.format {
+				t.Errorf("season/episode/format = %s/%s/%q, want %s/%s/%q", info.Season, info.Episode, info.VideoFormat, tt.season, tt.episode, tt.format)
+			}
+		})
+	}
+}
+
+func TestParseFileNameVolume(t *testing.T) {
+	tests := []struct {
+		name           string
+		volumeAsSeason bool
+		volume         string
+		season         string
+		episode        string
+		videoFormat    string
+	}{
+		{"Show.Vol.2.E03.1080p.mkv", false, "02", "01", "03", "1080P"},
+		{"Show.Vol.2.E03.1080p.mkv", true, "02", "02", "03", "1080P"},
+		{"Show Volume 3 - EP05 [x265].mkv", true, "03", "03", "05", ""},
+		{"Show.S01E04.Vol.2.mkv", true, "02", "01", "04", ""},
+		{"Show.S01E04.2160p.x265.mkv", true, "", "01", "04", "2160P"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParsePath(tt.name, ParseOptions{VolumeAsSeason: tt.volumeAsSeason})
+			if info.Volume != tt.volume {
+				t.Errorf("Volume = %q, want %q", info.Volume, tt.volume)
+			}
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+			if info.VideoFormat != tt.videoFormat {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.videoFormat)
+			}
+		})
+	}
+}
+
+func TestParseFileNameConflictingMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		title       string
+		season      string
+		episode     string
+		wantWarning bool
+	}{
+		// SxxExx 总是优先于仅集数规则（这里的 E05、- 07）
+		{"Show.S01E02.E05.mkv", "Show", "01", "02", false},
+		{"Show - 07 S02E03.mkv", "Show", "02", "03", false},
+		// 同一结果被多个规则识别，不提示
+		{"Show.S01E02.第1季第2集.mkv", "Show", "01", "02", false},
+		// 结果不同时采用离标题最近的一个
+		{"[2x09] Show S01E03.mkv", "Show", "01", "03", true},
+		{"[2x09] Show S01E03.mkv", "", "02", "09", true},
+		// 全角标题按转换后的长度计算位置，不会越过紧跟在标题后的 S01E03
+		{"Show S01E03 [2x09].mkv", "Ｓｈｏｗ", "01", "03", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.title, func(t *testing.T) {
+			info := ParsePath(tt.name, ParseOptions{Title: tt.title})
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+			if (info.Warning != "") != tt.wantWarning {
+				t.Errorf("Warning = %q, want warning: %v", info.Warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParseFileNamePercentDecode(t *testing.T) {
+	opts := ParseOptions{PercentDecode: true}
+	if info := ParsePath("Show%20%5BWEB%5D%20S01E02%201080p.mkv", opts); info.Season != "01" || info.Episode != "02" || info.VideoFormat != "1080P" {
+		t.Errorf("ParsePath() = %+v, want S01E02 1080P", info)
+	}
+	// 不是合法URL编码的百分号保持原样
+	if info := ParsePath("Show 100% S01E03.mkv", opts); info.Episode != "03" {
+		t.Errorf("ParsePath() with literal percent = %+v", info)
+	}
+	// 未开启时不解码
+	if info := ParsePath("Show%20S01E04%201080p.mkv", ParseOptions{}); info.VideoFormat != "" {
+		t.Errorf("ParsePath() without decoding VideoFormat = %q, want empty", info.VideoFormat)
+	}
+}
+
+func TestParseFileNameDotSeparatedFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		videoFormat string
+		codec       string
+	}{
+		{"Show.2160p.HDR.DV.mkv", "2160P.HDR.DV", ""},
+		{"Show.S01E01.2160p.DoVi.HDR10+.HEVC.mkv", "2160P.DV.HDR10+", "HEVC"},
+		{"Show.S01E01.1080p.HDR10.x265.mkv", "1080P.HDR10", "x265"},
+		// 后面紧跟字母数字的不是格式标记
+		{"Show.S01E01.720p.HDRip.DVDRip.mkv", "720P", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.VideoFormat != tt.videoFormat {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.videoFormat)
+			}
+			if info.Codec != tt.codec {
+				t.Errorf("Codec = %q, want %q", info.Codec, tt.codec)
+			}
+		})
+	}
+}
+
+func TestParseFileNameYear(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Movie.2010.1080p.BluRay.mkv", "2010"},
+		{"Movie (1999) [1080p].mkv", "1999"},
+		{"2012.2009.1080p.mkv", "2009"},
+		{"Show.S01E01.1920x1080.mkv", ""},
+		{"Show.S01E01.1080p.x2019.mkv", ""},
+		{"Show.S01E01.mkv", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseFileName(tt.name).Year; got != tt.want {
+			t.Errorf("ParseFileName(%q).Year = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileNameReleaseInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		audio  string
+		group  string
+	}{
+		{"Jade Dynasty S03E01 2025 2160p WEB-DL H265 DDP2.0-ADWeb.mkv", "WEB-DL", "DDP2.0", "ADWeb"},
+		{"Show.S01E01.1080p.BluRay.TrueHD.Atmos.x265-GRP.mkv", "BluRay", "TrueHD", "GRP"},
+		{"[SubsPlease] Show - 05 (1080p).mkv", "", "", "SubsPlease"},
+		{"Spider-Man.S01E01.mkv", "", "", ""},
+		{"Show.S01E01.Pilot.1080p.WEB-DL.mkv", "WEB-DL", "", ""},
+		{"Movie.2010.1080p.Blu-Ray.mkv", "BluRay", "", ""},
+		{"Movie.2010.720p.BR-Rip.mkv", "BRRip", "", ""},
+		{"Movie.2010.1080p.BluRay.DTS-HD.mkv", "BluRay", "DTS-HD", ""},
+		{"Show.S01E01.1080p.WEB-DL.x264-GRP.mkv", "WEB-DL", "", "GRP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Source != tt.source || info.Audio != tt.audio || info.Group != tt.group {
+				t.Errorf("source/audio/group = %q/%q/%q, want %q/%q/%q", info.Source, info.Audio, info.Group, tt.source, tt.audio, tt.group)
+			}
+		})
+	}
+}
+
+func TestParseFileNameSource(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Movie.2010.1080p.BluRay.x264-GRP.mkv", "BluRay"},
+		{"Movie.2010.1080p.Blu-Ray.x264.mkv", "BluRay"},
+		{"Movie.2010.720p.BDRip.x264.mkv", "BDRip"},
+		{"Movie.2010.720p.BRRip.x264.mkv", "BRRip"},
+		{"Movie.2010.720p.BR-Rip.mkv", "BRRip"},
+		{"Show.S01E01.1080p.WEB-DL.H264.mkv", "WEB-DL"},
+		{"Show.S01E01.1080p.webdl.mkv", "WEB-DL"},
+		{"Show.S01E01.1080p.WEBRip.x265.mkv", "WEBRip"},
+		{"Show.S01E01.720p.HDTV.x264.mkv", "HDTV"},
+		{"Movie.2010.DVDRip.XviD.avi", "DVDRip"},
+		{"Movie.2010.2160p.BluRay.REMUX.HEVC.mkv", "Remux"},
+		{"Movie.2010.1080p.BDRemux.mkv", "Remux"},
+		{"Movie.2010.1080p.mkv", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Source != tt.want {
+				t.Errorf("Source = %q, want %q", info.Source, tt.want)
+			}
+			data := NameData{Title: "Movie"}.WithFileInfo(info)
+			want := "Movie"
+			if tt.want != "" {
+				want += "." + tt.want
+			}
+			if got := RenderName("{title}.{source}", data); got != want {
+				t.Errorf("RenderName() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseFileNameFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  string
+		format string
+		codec  string
+	}{
+		{"Show.S01E01.1080p.WEB-DL.DUAL.DDP5.1.H.264-GRP.mkv", "DUAL", "1080P", "H264"},
+		{"Show.S02E03.2160p.BluRay.REMUX.HEVC.MULTi.2Audio-GRP.mkv", "MULTi.2Audio", "2160P", "HEVC"},
+		{"[字幕组] Show 第01集 1080p 简繁中字 x265.mp4", "简繁中字", "1080P", "x265"},
+		{"Show.S01E05.4K.国粤双语.繁中.mkv", "国粤双语.繁中", "2160P", ""},
+		{"Dual.Survival.S01E01.1080p.mkv", "", "1080P", ""},
+		{"Show.S01E01.1080p.Dual-Audio.mkv", "Dual-Audio", "1080P", ""},
+		{"Show.S01E01.1080p.mkv", "", "1080P", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Flags != tt.flags {
+				t.Errorf("Flags = %q, want %q", info.Flags, tt.flags)
+			}
+			if info.VideoFormat != tt.format || info.Codec != tt.codec {
+				t.Errorf("format/codec = %q/%q, want %q/%q", info.VideoFormat, info.Codec, tt.format, tt.codec)
+			}
+		})
+	}
+
+	data := NameData{Title: "Show", MediaType: "tv", TMDBID: 1}.WithFileInfo(ParseFileName("Show.S01E01.1080p.DUAL.mkv"))
+	if got, want := RenderName("{title}.S{season}E{episode}.{format}.{flags}", data), "Show.S01E01.1080p.DUAL"; got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+}
+
+func TestParseFileNameNoExtension(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		codec        string
+		episodeTitle string
+	}{
+		{"Show.S01E01", "", "", ""},
+		{"Show.S01E01.Part", "", "", "Part"},
+		{"Show.S01E01.1080p.x265", "1080P", "x265", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != "01" || info.Episode != "01" {
+				t.Errorf("season/episode = %q/%q, want 01/01", info.Season, info.Episode)
+			}
+			if info.VideoFormat != tt.format || info.Codec != tt.codec || info.EpisodeTitle != tt.episodeTitle {
+				t.Errorf("format/codec/episode title = %q/%q/%q, want %q/%q/%q",
+					info.VideoFormat, info.Codec, info.EpisodeTitle, tt.format, tt.codec, tt.episodeTitle)
+			}
+			if ext := FileExt(tt.name); ext != "" {
+				t.Errorf("FileExt() = %q, want empty", ext)
+			}
+		})
+	}
+
+	data := NameData{Title: "Show", Year: "2020", MediaType: "tv", TMDBID: 1}.WithFileInfo(ParseFileName("Show.S01E01"))
+	if got, want := RenderName(DefaultTVTemplate, data), "Show.2020.S01E01.{[tmdbid=1;type=tv]}"; got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+	if got := FileExt("Show.S01E01.MKV"); got != ".MKV" {
+		t.Errorf("FileExt() = %q, want .MKV", got)
+	}
+}
+
+func TestParseFileNameEpisodeZero(t *testing.T) {
+	tests := []struct {
+		name    string
+		season  string
+		episode string
+	}{
+		{"Show.S01E00.mkv", "01", "00"},
+		{"Show.S01E00.Pilot.1080p.mkv", "01", "00"},
+		{"Show.E00.mkv", "01", "00"},
+		{"Show.第零集.mkv", "01", "00"},
+		{"00.mkv", "01", "00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %q/%q, want %q/%q", info.Season, info.Episode, tt.season, tt.episode)
+			}
+		})
+	}
+
+	data := NameData{Title: "Show", MediaType: "tv", TMDBID: 1}.WithFileInfo(ParseFileName("Show.S01E00.mkv"))
+	if got, want := RenderName("{title}.S{season}E{episode}", data), "Show.S01E00"; got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+	data.EpisodePad = 1
+	if got, want := RenderName("{title}.S{season}E{episode}", data), "Show.S01E0"; got != want {
+		t.Errorf("RenderName() with episode pad 1 = %q, want %q", got, want)
+	}
+}
+
+func TestParseFileNameThreeDigitEpisode(t *testing.T) {
+	tests := []struct {
+		name         string
+		episode      string
+		episodeTitle string
+	}{
+		{"Show.S01E100.mkv", "100", ""},
+		{"Show.S01E100.Finale.1080p.mkv", "100", "Finale"},
+		{"Show.S01E05.1080p.mkv", "05", ""},
+		{"Show.S01E5.Pilot.mkv", "05", "Pilot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != "01" || info.Episode != tt.episode || info.EpisodeTitle != tt.episodeTitle {
+				t.Errorf("season/episode/episode title = %q/%q/%q, want 01/%q/%q", info.Season, info.Episode, info.EpisodeTitle, tt.episode, tt.episodeTitle)
+			}
+		})
+	}
+}
+
+func TestParseFileNameBareEpisode(t *testing.T) {
+	tests := []struct {
+		name    string
+		episode string
+	}{
+		{"01.mkv", "01"},
+		{"05 - Pilot.mkv", "05"},
+		{"12.1080p.mkv", "12"},
+		{"300.Rise.of.an.Empire.2014.1080p.mkv", ""},
+		{"007.Spectre.2015.mkv", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info := ParseFileName(tt.name); info.Episode != tt.episode {
+				t.Errorf("episode = %q, want %q", info.Episode, tt.episode)
+			}
+		})
+	}
+}
+
+func TestParsePathFolderSeason(t *testing.T) {
+	tests := []struct {
+		path   string
+		opts   ParseOptions
+		season string
+	}{
+		{"Breaking Bad/Season 3/Breaking.Bad.E05.1080p.mkv", ParseOptions{FolderSeason: true}, "03"},
+		{"Breaking Bad/S03/Breaking.Bad.E05.1080p.mkv", ParseOptions{FolderSeason: true}, "03"},
+		{"绝命毒师/第三季/绝命毒师.第05集.mkv", ParseOptions{FolderSeason: true}, "03"},
+		{"Show/Season 3/Show.E05.mkv", ParseOptions{}, "01"},
+		{"Show/Season 3/Show.S02E05.mkv", ParseOptions{FolderSeason: true}, "02"},
+		{"Show/Extras/Show.E05.mkv", ParseOptions{FolderSeason: true}, "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			info := ParsePath(filepath.FromSlash(tt.path), tt.opts)
+			if info.Season != tt.season {
+				t.Errorf("Season = %q, want %q", info.Season, tt.season)
+			}
+			if info.Episode != "05" {
+				t.Errorf("Episode = %q, want %q", info.Episode, "05")
+			}
+		})
+	}
+}
+
+func TestParsePathCompleteSeasonPack(t *testing.T) {
+	dir := filepath.Join("testdata", "packs")
+	files, _, err := FindMatchingFiles(dir, VideoFilePattern, MatchOptions{})
+	if err != nil {
+		t.Fatalf("FindMatchingFiles() error = %v", err)
+	}
+
+	want := map[string]string{
+		"Show.S01.Complete.1080p.WEB-DL/01.mkv": "S01E01",
+		"Show.S01.Complete.1080p.WEB-DL/02.mkv": "S01E02",
+		"Show Season 2 Complete/E01.mkv":        "S02E01",
+		"Show Season 2 Complete/E02.mkv":        "S02E02",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("FindMatchingFiles() = %q, want %d files", files, len(want))
+	}
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file)
+		info := ParsePath(file, ParseOptions{FolderSeason: true})
+		if got := "S" + info.Season + "E" + info.Episode; got != want[filepath.ToSlash(rel)] {
+			t.Errorf("ParsePath(%q) = %s, want %s", rel, got, want[filepath.ToSlash(rel)])
+		}
+	}
+
+	if season, ok := ParseSeasonFromDir("Show.S01.Complete.1080p"); !ok || season != "01" {
+		t.Errorf("ParseSeasonFromDir() = %q, %v, want 01", season, ok)
+	}
+}
+
+func TestAddPatterns(t *testing.T) {
+	savedSE, savedEO := seasonEpisodePatterns, episodeOnlyPatterns
+	defer func() { seasonEpisodePatterns, episodeOnlyPatterns = savedSE, savedEO }()
+
+	errs := AddPatterns(
+		[]string{`(\d{1,2})季(\d{1,3})话`, `(\d+`, `only(\d+)`},
+		[]string{`#(\d{1,3})`},
+	)
+	if len(errs) != 2 {
+		t.Fatalf("AddPatterns() returned %d errors, want 2: %v", len(errs), errs)
+	}
+
+	if info := ParseFileName("某剧.2季07话.mkv"); info.Season != "02" || info.Episode != "07" {
+		t.Errorf("extra season/episode pattern: got S%sE%s, want S02E07", info.Season, info.Episode)
+	}
+	if info := ParseFileName("某剧 #12.mkv"); info.Episode != "12" {
+		t.Errorf("extra episode-only pattern: got episode %q, want 12", info.Episode)
+	}
+}
+
+func TestParseFileNameFullWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		season      string
+		episode     string
+		videoFormat string
+	}{
+		{"某剧.第１２集.mkv", "01", "12", ""},
+		{"某剧　第０２季第０５集　１０８０Ｐ.mkv", "02", "05", "1080P"},
+		{"【字幕组】Ｓｈｏｗ．Ｓ０１Ｅ０３．７２０ｐ.mp4", "01", "03", "720P"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+			if info.VideoFormat != tt.videoFormat {
+				t.Errorf("VideoFormat = %q, want %q", info.VideoFormat, tt.videoFormat)
+			}
+		})
+	}
+}
+
+func TestNormalizeWidth(t *testing.T) {
+	if got, want := NormalizeWidth("第０１集（完）【ＨＤ】"), "第01集(完)[HD]"; got != want {
+		t.Errorf("NormalizeWidth() = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkParseFileName(b *testing.B) {
+	names := []string{
+		"Jade Dynasty S03E01 2025 2160p WEB-DL H265 DDP2.0-ADWeb.mkv",
+		"某剧.第12集.1080p.mkv",
+		"Show.Ep.05.720p.10bit.mkv",
+		"01.mkv",
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseFileName(names[i%len(names)])
+	}
+}
+
+func TestParseFileNameChineseNumerals(t *testing.T) {
+	tests := []struct {
+		name    string
+		season  string
+		episode string
+	}{
+		{"某剧.第十集.mkv", "01", "10"},
+		{"某剧.第二十三集.1080p.mkv", "01", "23"},
+		{"某剧.第一百集.mkv", "01", "100"},
+		{"某剧.第二季第十二集.mkv", "02", "12"},
+		{"某剧.第03集.mkv", "01", "03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Season != tt.season || info.Episode != tt.episode {
+				t.Errorf("season/episode = %s/%s, want %s/%s", info.Season, info.Episode, tt.season, tt.episode)
+			}
+		})
+	}
+}
+
+func TestParseChineseNumber(t *testing.T) {
+	tests := map[string]int{"十": 10, "十二": 12, "二十三": 23, "一百": 100, "一百零五": 105, "两": 2}
+	for s, want := range tests {
+		if got, ok := ParseChineseNumber(s); !ok || got != want {
+			t.Errorf("ParseChineseNumber(%q) = %d, %v, want %d", s, got, ok, want)
+		}
+	}
+	if _, ok := ParseChineseNumber("12"); ok {
+		t.Errorf("ParseChineseNumber(%q) should fail for arabic digits", "12")
+	}
+}
+
+func TestParsePathEpisodeOffset(t *testing.T) {
+	tests := []struct {
+		path    string
+		episode string
+		warning bool
+	}{
+		{"Show.S02E13.1080p.mkv", "01", false},
+		{"Show.S02E24.1080p.mkv", "12", false},
+		{"Show.S02E05.1080p.mkv", "05", true},
+		{"Show.E14.1080p.mkv", "02", false},
+	}
+	for _, tt := range tests {
+		info := ParsePath(tt.path, ParseOptions{EpisodeOffset: 12})
+		if info.Episode != tt.episode || (info.Warning != "") != tt.warning {
+			t.Errorf("ParsePath(%q) Episode = %q, Warning = %q, want %q, warning %v", tt.path, info.Episode, info.Warning, tt.episode, tt.warning)
+		}
+	}
+}
+
+func TestParsePathEpisodeMap(t *testing.T) {
+	// 绝对顺序：每季12集
+	opts := ParseOptions{EpisodeOffset: 1, EpisodeMap: func(season, episode int) (int, int, error) {
+		if episode > 24 {
+			return 0, 0, errors.New("没有该集")
+		}
+		return (episode-1)/12 + 1, (episode-1)%12 + 1, nil
+	}}
+	tests := []struct {
+		path            string
+		season, episode string
+		warning         bool
+	}{
+		{"Show.E14.1080p.mkv", "02", "01", false},
+		{"Show.S01E06.1080p.mkv", "01", "05", false},
+		{"Show.S01E40.1080p.mkv", "01", "39", true},
+		{"Show.1080p.mkv", "", "", false},
+	}
+	for _, tt := range tests {
+		info := ParsePath(tt.path, opts)
+		if info.Season != tt.season || info.Episode != tt.episode || (info.Warning != "") != tt.warning {
+			t.Errorf("ParsePath(%q) = S%sE%s, Warning = %q, want S%sE%s, warning %v", tt.path, info.Season, info.Episode, info.Warning, tt.season, tt.episode, tt.warning)
+		}
+	}
+}
+
+func TestParseFileNamePart(t *testing.T) {
+	tests := []struct {
+		name string
+		part string
+	}{
+		{"Movie.2010.DVDRip.CD1.avi", "CD1"},
+		{"Movie.2010.DVDRip.cd2.avi", "CD2"},
+		{"Movie 2010 Disc 1 720p.mkv", "Disc1"},
+		{"Movie.2010.Disk.02.720p.mkv", "Disc2"},
+		{"Movie.2010.1080p.HDCD.mkv", ""},
+		{"Discovery.2010.1080p.mkv", ""},
+		{"Movie.2010.1080p.mkv", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseFileName(tt.name)
+			if info.Part != tt.part {
+				t.Errorf("Part = %q, want %q", info.Part, tt.part)
+			}
+			// 分段序号不能被当作季集
+			if info.Season != "" || info.Episode != "" {
+				t.Errorf("Season/Episode = %q/%q, want empty", info.Season, info.Episode)
+			}
+		})
+	}
+
+	if info := ParseFileName("Show.S01E03.Disc1.1080p.mkv"); info.Episode != "03" || info.Part != "Disc1" || info.EpisodeTitle != "" {
+		t.Errorf("ParseFileName() = %+v, want E03 Disc1 without episode title", info)
+	}
+}
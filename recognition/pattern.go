@@ -0,0 +1,276 @@
+package recognition
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	seasonEpRegex = regexp.MustCompile(`S\d+E\d+`)
+	numberRegex   = regexp.MustCompile(`\d+`)
+	checksumRegex = regexp.MustCompile(`\\\[[0-9A-Fa-f]{8}\\\]|\\\([0-9A-Fa-f]{8}\\\)`) // 转义后的 [A1B2C3D4]、(A1B2C3D4)
+)
+
+func findCommonPattern(files []string, fixedTitle string) (string, string, string) {
+	if len(files) == 0 {
+		return "", "", ""
+	}
+
+	// 获取第一个文件的信息作为基准
+	firstFile := filepath.Base(files[0])
+	fileInfo := ParseFileName(firstFile)
+	videoFormat := fileInfo.VideoFormat
+
+	// 查找固定标题在文件名中的位置
+	idx := strings.Index(strings.ToLower(firstFile), strings.ToLower(fixedTitle))
+	if idx == -1 {
+		return "", "", ""
+	}
+
+	// 分割前缀和后缀
+	prefix := firstFile[:idx]
+	suffix := firstFile[idx+len(fixedTitle):]
+
+	// 从后缀中提取季集信息之前的部分
+	if loc := seasonEpRegex.FindStringIndex(suffix); loc != nil {
+		suffix = suffix[loc[0]:]
+	}
+
+	// 从后缀中提取视频格式之后的部分
+	if videoFormat != "" {
+		if idx := strings.Index(strings.ToUpper(suffix), videoFormat); idx != -1 {
+			suffix = suffix[:idx] + videoFormat + ".*"
+		}
+	}
+
+	// 将前缀和后缀中的特殊字符转换为正则表达式
+	prefix = regexp.QuoteMeta(prefix)
+	suffix = regexp.QuoteMeta(suffix)
+
+	// 替换掉后缀中视频格式后的具体字符
+	suffix = strings.Replace(suffix, `\.*`, `.*`, 1)
+
+	// 替换季集信息为通配符
+	suffix = seasonEpRegex.ReplaceAllString(suffix, `S(\d{1,2})E(\d{1,3})`)
+
+	return prefix, suffix, videoFormat
+}
+
+// MajorityFormat 返回files中出现次数最多的视频格式及其文件数，次数相同时取先出现的一个；
+// 没有识别出视频格式的文件不参与统计，都没有时返回空字符串和0
+func MajorityFormat(files []string, opts ParseOptions) (string, int) {
+	counts := make(map[string]int)
+	var order []string
+	for _, file := range files {
+		format := ParsePath(file, opts).VideoFormat
+		if format == "" {
+			continue
+		}
+		if counts[format] == 0 {
+			order = append(order, format)
+		}
+		counts[format]++
+	}
+	var majority string
+	for _, format := range order {
+		if counts[format] > counts[majority] {
+			majority = format
+		}
+	}
+	return majority, counts[majority]
+}
+
+// GenerateRegexPattern 分析所有匹配文件的共同前缀，返回批量规则的前缀、后缀和视频格式；
+// 视频格式按parseOpts解析，取自第一个文件，opts.MajorityFormat 时取多数文件的格式，避免第一个文件格式特殊时后缀只能匹配这一个文件
+func GenerateRegexPattern(files []string, fixedTitle string, opts PatternOptions, parseOpts ParseOptions) (string, string, string) {
+	if len(files) == 0 {
+		return "", "", ""
+	}
+
+	// 获取第一个文件的基本信息
+	firstFile := filepath.Base(files[0])
+	videoFormat := ParsePath(files[0], parseOpts).VideoFormat
+	if opts.MajorityFormat {
+		if format, _ := MajorityFormat(files, parseOpts); format != "" {
+			videoFormat = format
+		}
+	}
+
+	// 查找固定标题在文件名中的位置
+	idx := strings.Index(strings.ToLower(firstFile), strings.ToLower(fixedTitle))
+	if idx == -1 {
+		return "", "", ""
+	}
+
+	// 分析所有文件名，找出共同模式
+	commonPrefix := firstFile[:idx]
+
+	// 提取第一个文件的季集信息位置
+	firstFileSuffix := firstFile[idx+len(fixedTitle):]
+	seasonEpLoc := seasonEpRegex.FindStringIndex(firstFileSuffix)
+	if seasonEpLoc == nil {
+		return "", "", ""
+	}
+
+	// 分析所有文件，找出共同的前缀和后缀模式
+	for i, file := range files {
+		fileName := filepath.Base(file)
+		if i == 0 {
+			continue
+		}
+
+		// 查找当前文件中的固定标题位置
+		currIdx := strings.Index(strings.ToLower(fileName), strings.ToLower(fixedTitle))
+		if currIdx == -1 {
+			continue
+		}
+
+		// 更新共同前缀
+		currPrefix := fileName[:currIdx]
+		commonPrefix = findCommonPrefixPattern(commonPrefix, currPrefix)
+	}
+
+	// 构建最终的模式，先转义再把数字和数字占位符替换为通配符，避免通配符本身被转义
+	prefix := regexp.QuoteMeta(commonPrefix)
+	suffix := `S(\d{1,2})E(\d{1,3}).*` + regexp.QuoteMeta(videoFormat)
+
+	// 替换数字序列为通配符
+	prefix = numberRegex.ReplaceAllString(prefix, `\d+`)
+	prefix = strings.ReplaceAll(prefix, numberPlaceholder, `\d+`)
+
+	return prefix, suffix, videoFormat
+}
+
+// numberPlaceholder 共同前缀中代表任意数字序列的占位符，不会出现在文件名中，也不会被regexp.QuoteMeta转义
+const numberPlaceholder = "\x00"
+
+// findCommonPrefixPattern 返回两个前缀的共同部分（原样文本），其中连续数字替换为numberPlaceholder
+func findCommonPrefixPattern(a, b string) string {
+	// 如果前缀完全相同，直接返回
+	if a == b {
+		return a
+	}
+
+	// 将连续数字替换为单个占位符
+	a = numberRegex.ReplaceAllString(a, numberPlaceholder)
+	b = numberRegex.ReplaceAllString(b, numberPlaceholder)
+
+	// 查找共同的部分，数字占位符视为相同
+	var result strings.Builder
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			break
+		}
+		result.WriteByte(a[i])
+	}
+	return result.String()
+}
+
+// PatternOptions 控制批量匹配模式的生成
+type PatternOptions struct {
+	// KeepBetween 将标题与季集之间的文本（连同前面的分隔符）捕获为第1个分组，原样保留到输出中
+	KeepBetween bool
+	// Seasons 不为空时季数分组只匹配其中的季（允许前导零），用于逐季生成互不重叠的批量规则或只处理部分季
+	Seasons []int
+	// EpisodeTo 大于0时集数分组只匹配 EpisodeFrom 到 EpisodeTo 之间的集数（含两端），用于只处理一季中的部分剧集
+	EpisodeFrom, EpisodeTo int
+	// BackrefStyle 替换词中分组引用的写法，BackrefBackslash（默认，为空时相同）或 BackrefDollar
+	BackrefStyle string
+	// WholeName 单文件规则以去掉扩展名的完整文件名（原样转义，前后锚定）作为被替换词，不再按标题生成部分匹配的正则
+	WholeName bool
+	// MajorityFormat 批量规则的后缀使用多数文件的视频格式，而不是第一个文件的格式
+	MajorityFormat bool
+}
+
+// episodeGroup 返回集数分组的正则，与解析时一样允许 E100 这类三位数的集数
+func (o PatternOptions) episodeGroup() string {
+	if o.EpisodeTo <= 0 || o.EpisodeTo < o.EpisodeFrom {
+		return `(\d{1,3})`
+	}
+	// 从大到小排列，并要求后面不是数字，避免 E12 只匹配到 1、范围外的 E15 被当作 E1
+	numbers := make([]string, 0, o.EpisodeTo-o.EpisodeFrom+1)
+	for n := o.EpisodeTo; n >= o.EpisodeFrom; n-- {
+		numbers = append(numbers, strconv.Itoa(n))
+	}
+	return fmt.Sprintf(`(0*(?:%s))[^0-9]`, strings.Join(numbers, "|"))
+}
+
+// seasonGroup 返回季数分组的正则
+func (o PatternOptions) seasonGroup() string {
+	if len(o.Seasons) == 0 {
+		return `(\d{1,2})`
+	}
+	numbers := make([]string, len(o.Seasons))
+	for i, n := range o.Seasons {
+		numbers[i] = strconv.Itoa(n)
+	}
+	return fmt.Sprintf(`(0*(?:%s))`, strings.Join(numbers, "|"))
+}
+
+// 替换词中分组引用的写法
+const (
+	BackrefBackslash = "backslash" // \1，MoviePilot等工具使用
+	BackrefDollar    = "dollar"    // ${1}，Go、JavaScript等使用 $ 替换语法的工具使用
+)
+
+// ref 返回第n个分组的引用
+func (o PatternOptions) ref(n int) string {
+	if o.BackrefStyle == BackrefDollar {
+		return fmt.Sprintf("${%d}", n)
+	}
+	return fmt.Sprintf(`\%d`, n)
+}
+
+// References 返回替换词中季、集以及标题与季集之间文本对应的分组引用
+func (o PatternOptions) References() (season, episode, between string) {
+	if o.KeepBetween {
+		return o.ref(2), o.ref(3), o.ref(1)
+	}
+	return o.ref(1), o.ref(2), ""
+}
+
+// EscapeReplacement 转义替换词中的字面文本（如标题中的 $），使其不会被当作分组引用
+func (o PatternOptions) EscapeReplacement(s string) string {
+	if o.BackrefStyle == BackrefDollar {
+		return strings.ReplaceAll(s, "$", "$$")
+	}
+	return s
+}
+
+// BuildBatchMatchPattern 构建批量规则的匹配模式
+func BuildBatchMatchPattern(fixedTitle string, opts PatternOptions) string {
+	if opts.KeepBetween {
+		return fmt.Sprintf("%s((?:[\\s._-]+.*?)??)[\\s._-]*[Ss]%s[Ee]%s\\.?.*?[0-9]+[pPkK]\\.?.*",
+			regexp.QuoteMeta(fixedTitle), opts.seasonGroup(), opts.episodeGroup())
+	}
+	return fmt.Sprintf("%s\\.?.*?[Ss]%s[Ee]%s\\.?.*?[0-9]+[pPkK]\\.?.*",
+		regexp.QuoteMeta(fixedTitle), opts.seasonGroup(), opts.episodeGroup())
+}
+
+// BetweenText 返回文件名中标题与季集之间的文本（连同前面的分隔符），没有时返回空字符串
+func BetweenText(fileName, fixedTitle string) string {
+	re := regexp.MustCompile(BuildBatchMatchPattern(fixedTitle, PatternOptions{KeepBetween: true}))
+	if matches := re.FindStringSubmatch(fileName); len(matches) == 4 {
+		return matches[1]
+	}
+	return ""
+}
+
+// QuoteFileName 将文件名转义为正则表达式，其中方括号或圆括号中的8位十六进制CRC32校验码替换为通配，
+// 使同一组发布的其他文件（校验码各不相同）也能匹配
+func QuoteFileName(fileName string) string {
+	return checksumRegex.ReplaceAllStringFunc(regexp.QuoteMeta(fileName), func(m string) string {
+		if m[1] == '[' {
+			return `\[[0-9A-Fa-f]{8}\]`
+		}
+		return `\([0-9A-Fa-f]{8}\)`
+	})
+}
+
+// QuoteWholeName 将去掉扩展名的完整文件名原样转义并前后锚定，只匹配这一个文件
+func QuoteWholeName(fileName string) string {
+	return "^" + regexp.QuoteMeta(strings.TrimSuffix(fileName, FileExt(fileName))) + "$"
+}
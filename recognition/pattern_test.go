@@ -0,0 +1,277 @@
+package recognition
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBetweenText(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"Jade Dynasty.Part Two.S03E01.2160p.mkv", ".Part Two"},
+		{"Jade Dynasty S03E01 2160p.mkv", ""},
+		{"Jade Dynasty.S03E01.2160p.mkv", ""},
+	}
+	for _, tt := range tests {
+		if got := BetweenText(tt.fileName, "Jade Dynasty"); got != tt.want {
+			t.Errorf("BetweenText(%q) = %q, want %q", tt.fileName, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteFileNameChecksum(t *testing.T) {
+	pattern := QuoteFileName("[Group] Show - 01 [1080p][A1B2C3D4].mkv")
+	want := `\[Group\] Show - 01 \[1080p\]\[[0-9A-Fa-f]{8}\]\.mkv`
+	if pattern != want {
+		t.Fatalf("QuoteFileName() = %q, want %q", pattern, want)
+	}
+	re := regexp.MustCompile(pattern)
+	if !re.MatchString("[Group] Show - 01 [1080p][0F9E8D7C].mkv") {
+		t.Errorf("pattern %q does not match a file with a different CRC", pattern)
+	}
+
+	if got := QuoteFileName("Show (DEADBEEF).mkv"); got != `Show \([0-9A-Fa-f]{8}\)\.mkv` {
+		t.Errorf("QuoteFileName() = %q", got)
+	}
+	if got := QuoteFileName("Show [1080p].mkv"); got != regexp.QuoteMeta("Show [1080p].mkv") {
+		t.Errorf("QuoteFileName() without checksum = %q", got)
+	}
+}
+
+func TestPatternsWithMetacharacterTitles(t *testing.T) {
+	tests := []struct {
+		title string
+		files []string
+	}{
+		{"S.W.A.T.", []string{"S.W.A.T.2017.S01E01.1080p.mkv", "S.W.A.T.2017.S01E02.1080p.mkv"}},
+		{"Spider-Man (2002)", []string{"[Grp1] Spider-Man (2002) S01E01 1080p.mkv", "[Grp2] Spider-Man (2002) S01E02 1080p.mkv"}},
+		{"C++ [Part+1]", []string{"C++ [Part+1].S02E03.720p.mkv", "C++ [Part+1].S02E04.720p.mkv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			prefix, suffix, _ := GenerateRegexPattern(tt.files, tt.title, PatternOptions{}, ParseOptions{})
+			if suffix == "" {
+				t.Fatalf("GenerateRegexPattern() suffix is empty")
+			}
+			prefixRe, err := regexp.Compile("^" + prefix)
+			if err != nil {
+				t.Fatalf("prefix %q does not compile: %v", prefix, err)
+			}
+
+			re := regexp.MustCompile(BuildBatchMatchPattern(tt.title, PatternOptions{}))
+			for _, file := range tt.files {
+				if !prefixRe.MatchString(file) {
+					t.Errorf("prefix %q does not match %q", prefix, file)
+				}
+				m := re.FindStringSubmatch(file)
+				if m == nil {
+					t.Errorf("batch pattern %q does not match %q", re, file)
+					continue
+				}
+				if info := ParseFileName(file); m[2] != info.Episode {
+					t.Errorf("batch pattern captured episode %q from %q, want %q", m[2], file, info.Episode)
+				}
+			}
+
+			// 标题中的 . 不能当作任意字符
+			if strings.Contains(tt.title, ".") && re.MatchString(strings.ReplaceAll(tt.files[0], ".", "x")) {
+				t.Errorf("batch pattern %q treats '.' in the title as a wildcard", re)
+			}
+		})
+	}
+}
+
+func TestBatchPatternEpisodeTitles(t *testing.T) {
+	files := []string{
+		"Show.S01E01.Pilot.1080p.WEB-DL.mkv",
+		"Show.S01E02.1080p.WEB-DL.mkv",
+		"Show.S01E03.24.Hours.Later.1080p.WEB-DL.mkv",
+		"Show.S01E04.Part.2.1080p.WEB-DL.mkv",
+	}
+	titles := []string{"Pilot", "", "24.Hours.Later", "Part.2"}
+
+	re := regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{}))
+	prefix, suffix, _ := GenerateRegexPattern(files, "Show", PatternOptions{}, ParseOptions{})
+	if prefix != "" || suffix == "" {
+		t.Fatalf("GenerateRegexPattern() = %q, %q", prefix, suffix)
+	}
+	for i, file := range files {
+		m := re.FindStringSubmatch(file)
+		if m == nil || m[1] != "01" || m[2] != fmt.Sprintf("%02d", i+1) {
+			t.Errorf("batch pattern on %q = %q, want S01E%02d", file, m, i+1)
+		}
+		if got := ParseFileName(file).EpisodeTitle; got != titles[i] {
+			t.Errorf("EpisodeTitle(%q) = %q, want %q", file, got, titles[i])
+		}
+	}
+}
+
+func TestBatchPatternSeason(t *testing.T) {
+	re := regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{Seasons: []int{2}}))
+	for file, want := range map[string]bool{
+		"Show.S02E01.1080p.mkv": true,
+		"Show.S2E03.1080p.mkv":  true,
+		"Show.S01E01.1080p.mkv": false,
+		"Show.S12E01.1080p.mkv": false,
+	} {
+		if got := re.MatchString(file); got != want {
+			t.Errorf("pattern %q MatchString(%q) = %v, want %v", re, file, got, want)
+		}
+	}
+	if m := re.FindStringSubmatch("Show.S02E05.1080p.mkv"); m == nil || m[1] != "02" || m[2] != "05" {
+		t.Errorf("FindStringSubmatch() = %q", m)
+	}
+
+	re = regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{Seasons: []int{1, 12}}))
+	for _, file := range []string{"Show.S01E01.1080p.mkv", "Show.S12E01.1080p.mkv"} {
+		if !re.MatchString(file) {
+			t.Errorf("pattern %q does not match %q", re, file)
+		}
+	}
+	if re.MatchString("Show.S02E01.1080p.mkv") {
+		t.Errorf("pattern %q matches season 2", re)
+	}
+
+	re = regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{KeepBetween: true, Seasons: []int{1}}))
+	if m := re.FindStringSubmatch("Show.Part.Two.S01E02.1080p.mkv"); m == nil || m[1] != ".Part.Two" || m[2] != "01" || m[3] != "02" {
+		t.Errorf("FindStringSubmatch() with KeepBetween = %q", m)
+	}
+}
+
+func TestBatchPatternEpisodeRange(t *testing.T) {
+	re := regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{EpisodeFrom: 5, EpisodeTo: 12}))
+	for file, want := range map[string]string{
+		"Show.S01E05.1080p.mkv": "05",
+		"Show.S01E12.1080p.mkv": "12",
+		"Show.S01E9.1080p.mkv":  "9",
+		"Show.S01E04.1080p.mkv": "",
+		"Show.S01E13.1080p.mkv": "",
+		"Show.S01E15.1080p.mkv": "",
+		"Show.S01E1.1080p.mkv":  "",
+	} {
+		got := ""
+		if m := re.FindStringSubmatch(file); m != nil {
+			got = m[2]
+		}
+		if got != want {
+			t.Errorf("pattern %q on %q captured episode %q, want %q", re, file, got, want)
+		}
+	}
+}
+
+func TestPatternOptionsBackrefStyle(t *testing.T) {
+	tests := []struct {
+		opts                     PatternOptions
+		season, episode, between string
+	}{
+		{PatternOptions{}, `\1`, `\2`, ""},
+		{PatternOptions{KeepBetween: true}, `\2`, `\3`, `\1`},
+		{PatternOptions{BackrefStyle: BackrefDollar}, "${1}", "${2}", ""},
+		{PatternOptions{KeepBetween: true, BackrefStyle: BackrefDollar}, "${2}", "${3}", "${1}"},
+	}
+	for _, tt := range tests {
+		season, episode, between := tt.opts.References()
+		if season != tt.season || episode != tt.episode || between != tt.between {
+			t.Errorf("%+v References() = %q, %q, %q, want %q, %q, %q", tt.opts, season, episode, between, tt.season, tt.episode, tt.between)
+		}
+	}
+
+	if got := (PatternOptions{BackrefStyle: BackrefDollar}).EscapeReplacement("Ca$h"); got != "Ca$$h" {
+		t.Errorf("EscapeReplacement() = %q, want Ca$$h", got)
+	}
+	if got := (PatternOptions{}).EscapeReplacement("Ca$h"); got != "Ca$h" {
+		t.Errorf("EscapeReplacement() with backslash style = %q, want Ca$h", got)
+	}
+
+	// ${1} 形式的替换词可以直接用于Go的regexp
+	re := regexp.MustCompile(BuildBatchMatchPattern("Show", PatternOptions{}))
+	if got := re.ReplaceAllString("Show.S01E02.1080p.mkv", "Ca$$h.S${1}E${2}"); got != "Ca$h.S01E02" {
+		t.Errorf("ReplaceAllString() = %q", got)
+	}
+}
+
+func TestQuoteWholeName(t *testing.T) {
+	tests := []struct {
+		fileName, want string
+	}{
+		{"Inception.2010.1080p.BluRay.mkv", `^Inception\.2010\.1080p\.BluRay$`},
+		{"[Group] Movie (2010) [A1B2C3D4].mp4", `^\[Group\] Movie \(2010\) \[A1B2C3D4\]$`},
+		{"Movie.2010.x265", `^Movie\.2010\.x265$`},
+	}
+	for _, tt := range tests {
+		got := QuoteWholeName(tt.fileName)
+		if got != tt.want {
+			t.Errorf("QuoteWholeName(%q) = %q, want %q", tt.fileName, got, tt.want)
+			continue
+		}
+		name := strings.TrimSuffix(tt.fileName, FileExt(tt.fileName))
+		if re := regexp.MustCompile(got); !re.MatchString(name) || re.MatchString(name+".Extra") {
+			t.Errorf("pattern %q does not match exactly %q", got, name)
+		}
+	}
+}
+
+func TestMajorityFormat(t *testing.T) {
+	tests := []struct {
+		files  []string
+		format string
+		count  int
+	}{
+		{[]string{"Show.S01E01.720p.mkv", "Show.S01E02.1080p.mkv", "Show.S01E03.1080p.mkv"}, "1080P", 2},
+		{[]string{"Show.S01E01.720p.mkv", "Show.S01E02.1080p.mkv", "Show.S01E03.1080p.mkv", "Show.S01E04.720p.mkv"}, "720P", 2},
+		{[]string{"Show.S01E01.mkv", "Show.S01E02.2160p.HDR.mkv"}, "2160P.HDR", 1},
+		{[]string{"Show.S01E01.mkv"}, "", 0},
+	}
+	for _, tt := range tests {
+		format, count := MajorityFormat(tt.files, ParseOptions{})
+		if format != tt.format || count != tt.count {
+			t.Errorf("MajorityFormat(%q) = %q, %d, want %q, %d", tt.files, format, count, tt.format, tt.count)
+		}
+	}
+
+	files := []string{"Show.S01E01.720p.mkv", "Show.S01E02.1080p.mkv", "Show.S01E03.1080p.mkv"}
+	_, suffix, format := GenerateRegexPattern(files, "Show", PatternOptions{MajorityFormat: true}, ParseOptions{})
+	if format != "1080P" || !strings.HasSuffix(suffix, "1080P") {
+		t.Errorf("GenerateRegexPattern() suffix, format = %q, %q, want the majority format 1080P", suffix, format)
+	}
+	_, suffix, format = GenerateRegexPattern(files, "Show", PatternOptions{}, ParseOptions{})
+	if format != "720P" || !strings.HasSuffix(suffix, "720P") {
+		t.Errorf("GenerateRegexPattern() without MajorityFormat suffix, format = %q, %q, want the first file's format 720P", suffix, format)
+	}
+	files = []string{"Show.S01E01.4K.mkv", "Show.S01E02.4K.mkv"}
+	if _, _, format = GenerateRegexPattern(files, "Show", PatternOptions{}, ParseOptions{KeepResolutionLabel: true}); format != "4K" {
+		t.Errorf("GenerateRegexPattern() with KeepResolutionLabel format = %q, want 4K", format)
+	}
+}
+
+func TestBatchPatternThreeDigitEpisode(t *testing.T) {
+	files := []string{"Show.S01E99.1080p.mkv", "Show.S01E100.Finale.1080p.mkv"}
+	_, suffix, _ := GenerateRegexPattern(files, "Show", PatternOptions{}, ParseOptions{})
+	if m := regexp.MustCompile("(?i)" + suffix).FindStringSubmatch(files[1]); m == nil || m[2] != "100" {
+		t.Errorf("suffix %q on %q = %q, want episode 100", suffix, files[1], m)
+	}
+
+	tests := []struct {
+		opts PatternOptions
+		want string
+	}{
+		{PatternOptions{}, "Show S01E100"},
+		{PatternOptions{EpisodeFrom: 99, EpisodeTo: 101}, "Show S01E100"},
+		{PatternOptions{EpisodeFrom: 1, EpisodeTo: 10}, ""},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(BuildBatchMatchPattern("Show", tt.opts))
+		got := ""
+		if re.MatchString(files[1]) {
+			got = re.ReplaceAllString(files[1], "Show S${1}E${2}")
+		}
+		if got != tt.want {
+			t.Errorf("pattern %q on %q renders %q, want %q", re, files[1], got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package recognition
+
+import "sort"
+
+// Preset 媒体服务器命名预设，包含文件名模板和ID标签格式
+type Preset struct {
+	Name          string
+	Description   string
+	MovieTemplate string
+	TVTemplate    string
+	TagFormat     string
+}
+
+// Presets 内置的媒体服务器命名预设
+var Presets = map[string]Preset{
+	"jellyfin": {
+		Name:          "jellyfin",
+		Description:   "Jellyfin：Title (Year) S01E01 [tmdbid-123]",
+		MovieTemplate: "{title} ({year}) {tag}",
+		TVTemplate:    "{title} ({year}) S{season}E{episode} {tag}",
+		TagFormat:     "[tmdbid-{tmdbid}]",
+	},
+	"emby": {
+		Name:          "emby",
+		Description:   "Emby：Title (Year) - S01E01 [tmdbid=123]",
+		MovieTemplate: "{title} ({year}) {tag}",
+		TVTemplate:    "{title} ({year}) - S{season}E{episode} {tag}",
+		TagFormat:     "[tmdbid={tmdbid}]",
+	},
+	"chinese": {
+		Name:          "chinese",
+		Description:   "中文季集：标题.年份.第01季第01集",
+		MovieTemplate: DefaultMovieTemplate,
+		TVTemplate:    LocalizeEpisode(DefaultTVTemplate),
+	},
+	"plex": {
+		Name:          "plex",
+		Description:   "Plex：Title (Year) - s01e01 {tmdb-123}",
+		MovieTemplate: "{title} ({year}) {tag}",
+		TVTemplate:    "{title} ({year}) - s{season}e{episode} {tag}",
+		TagFormat:     "{tmdb-{tmdbid}}",
+	},
+}
+
+// PresetNames 返回按名称排序的预设列表
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+package recognition
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// splitRegex 分卷文件的后缀：紧跟在视频扩展名之后的 .001、.002 或 .part1、.part02
+var splitRegex = regexp.MustCompile(`(?i)\.(?:mkv|mp4|avi|ts|m2ts|rmvb|wmv|mov|flv|webm|iso)(\.(?:part)?(\d{1,3}))$`)
+
+// SplitPart 返回分卷文件名（或路径）末尾的分卷后缀（如 .001、.part2）及其序号，不是分卷文件时返回空字符串和0；
+// 不带 part 的数字后缀需为3位，避免把 Show.mkv.1 之类的文件当作分卷
+func SplitPart(name string) (suffix string, n int) {
+	m := splitRegex.FindStringSubmatch(name)
+	if m == nil || (!strings.HasPrefix(strings.ToLower(m[1]), ".part") && len(m[2]) != 3) {
+		return "", 0
+	}
+	n, _ = strconv.Atoi(m[2])
+	return m[1], n
+}
+
+// SplitBase 返回去掉分卷后缀后的文件名（或路径），同一组分卷的结果相同；不是分卷文件时原样返回
+func SplitBase(name string) string {
+	suffix, _ := SplitPart(name)
+	return strings.TrimSuffix(name, suffix)
+}
+
+// SplitGroups 将files中的分卷文件按去掉分卷后缀后的名称分组，按各组第一个文件出现的顺序返回，不是分卷的文件不包含在内
+func SplitGroups(files []string) [][]string {
+	index := make(map[string]int)
+	var groups [][]string
+	for _, file := range files {
+		if suffix, _ := SplitPart(file); suffix == "" {
+			continue
+		}
+		base := SplitBase(file)
+		i, ok := index[base]
+		if !ok {
+			i = len(groups)
+			index[base] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], file)
+	}
+	return groups
+}
@@ -0,0 +1,64 @@
+package recognition
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitPart(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		n      int
+		ext    string
+	}{
+		{"Show.S01E01.1080p.mkv.001", ".001", 1, ".mkv.001"},
+		{"Show.S01E01.1080p.MKV.002", ".002", 2, ".MKV.002"},
+		{"Show.S01E01.1080p.mp4.part2", ".part2", 2, ".mp4.part2"},
+		{"Show.S01E01.1080p.mkv", "", 0, ".mkv"},
+		{"Show.S01E01.1080p.mkv.1", "", 0, ""},
+		{"Show.S01E01.1080p.zip.001", "", 0, ""},
+	}
+	for _, tt := range tests {
+		suffix, n := SplitPart(tt.name)
+		if suffix != tt.suffix || n != tt.n {
+			t.Errorf("SplitPart(%q) = %q, %d, want %q, %d", tt.name, suffix, n, tt.suffix, tt.n)
+		}
+		if got := FileExt(tt.name); got != tt.ext {
+			t.Errorf("FileExt(%q) = %q, want %q", tt.name, got, tt.ext)
+		}
+	}
+
+	info := ParseFileName("Show.S01E02.1080p.mkv.002")
+	if info.Season != "01" || info.Episode != "02" || info.VideoFormat != "1080P" {
+		t.Errorf("ParseFileName() of a split file = %s/%s/%q, want 01/02/1080P", info.Season, info.Episode, info.VideoFormat)
+	}
+}
+
+func TestSplitGroups(t *testing.T) {
+	files := []string{
+		"Show.S01E01.1080p.mkv.001",
+		"Show.S01E02.1080p.mkv",
+		"Show.S01E01.1080p.mkv.002",
+		"Show.S01E03.1080p.mkv.part1",
+		"Show.S01E03.1080p.mkv.part2",
+	}
+	got := SplitGroups(files)
+	want := [][]string{
+		{"Show.S01E01.1080p.mkv.001", "Show.S01E01.1080p.mkv.002"},
+		{"Show.S01E03.1080p.mkv.part1", "Show.S01E03.1080p.mkv.part2"},
+	}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("SplitGroups() = %q, want %q", got, want)
+	}
+
+	// 同一组分卷一起保留或跳过，不会被当作同一集的重复文件
+	files = append(files, "Show.S01E01.720p.mkv")
+	kept, skipped := Dedupe(files, ParseOptions{})
+	if want := []string{"Show.S01E01.720p.mkv"}; !slices.Equal(skipped, want) {
+		t.Errorf("Dedupe() skipped = %q, want %q", skipped, want)
+	}
+	if len(kept) != 5 {
+		t.Errorf("Dedupe() kept = %q, want all 5 split and plain files", kept)
+	}
+}
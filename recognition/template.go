@@ -0,0 +1,207 @@
+package recognition
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 默认文件名模板，{year}为空时相邻的分隔符和空括号会被自动清理
+const (
+	DefaultMovieTemplate = "{title}.{year}.{format}.{tag}"
+	DefaultTVTemplate    = "{title}.{year}.S{season}E{episode}.{format}.{tag}"
+)
+
+// seasonEpisodeToken 模板中 S{season}E{episode}（大小写均可）形式的季集标记
+var seasonEpisodeToken = regexp.MustCompile(`(?i)S\{season\}E\{episode\}`)
+
+// LocalizeEpisode 将模板中的 S{season}E{episode} 替换为中文的 第{season}季第{episode}集，季集的识别不受影响；
+// 电影模板等没有季集标记的模板保持不变
+func LocalizeEpisode(template string) string {
+	return seasonEpisodeToken.ReplaceAllLiteralString(template, "第{season}季第{episode}集")
+}
+
+// NameData 渲染文件名模板所需的数据
+type NameData struct {
+	Title     string
+	Year      string
+	EndYear   string // 已完结电视剧最后播出的年份，连载中或电影为空
+	Season    string
+	Episode   string
+	Between   string // 标题与季集之间保留的原始文本
+	Format    string
+	BitDepth  string
+	Codec     string
+	Source    string // 片源，如 BluRay、BDRip、WEB-DL、Remux
+	Volume    string
+	Flags     string // 多音轨、多字幕标记，如 DUAL、繁中
+	Part      string // 分段文件的序号标记，如 CD1、Disc2
+	Status    string // TMDB状态，如 Returning Series、Ended
+	Provider  string // 订阅即可观看的流媒体服务，如 Netflix，未请求或没有时为空
+	MediaType string
+	TMDBID    int
+	TagFormat string // {tag}的格式，可使用{tmdbid}和{type}，为空时使用 {[tmdbid=...;type=...]}
+	TagType   string // {tag}和{type}中的媒体类型，为空时与MediaType相同
+
+	SeasonPad  int // {season}补零后的位数，如 1 输出 S1、3 输出 S001；0表示保持解析结果
+	EpisodePad int // {episode}补零后的位数，含义同SeasonPad
+}
+
+// WithFileInfo 填入从文件名解析出的季集、视频格式等信息
+func (d NameData) WithFileInfo(info FileInfo) NameData {
+	d.Season = info.Season
+	d.Episode = info.Episode
+	d.Format = strings.ToLower(info.VideoFormat)
+	d.BitDepth = info.BitDepth
+	d.Codec = info.Codec
+	d.Source = info.Source
+	d.Volume = info.Volume
+	d.Flags = info.Flags
+	d.Part = info.Part
+	return d
+}
+
+func (d NameData) tagType() string {
+	if d.TagType != "" {
+		return d.TagType
+	}
+	return d.MediaType
+}
+
+func (d NameData) tag() string {
+	if d.TagFormat == "" {
+		return fmt.Sprintf("{[tmdbid=%d;type=%s]}", d.TMDBID, d.tagType())
+	}
+	return substitute(d.TagFormat, map[string]string{
+		"tmdbid": strconv.Itoa(d.TMDBID),
+		"type":   d.tagType(),
+	})
+}
+
+func (d NameData) values() map[string]string {
+	return map[string]string{
+		"title":     pathSafe(d.Title),
+		"year":      d.Year,
+		"yearRange": d.yearRange(),
+		"season":    padNumber(d.Season, d.SeasonPad),
+		"episode":   padNumber(d.Episode, d.EpisodePad),
+		"between":   d.Between,
+		"format":    d.Format,
+		"bitdepth":  d.BitDepth,
+		"codec":     d.Codec,
+		"source":    d.Source,
+		"volume":    d.Volume,
+		"flags":     d.Flags,
+		"part":      d.Part,
+		"status":    d.Status,
+		"provider":  d.Provider,
+		"tmdbid":    strconv.Itoa(d.TMDBID),
+		"type":      d.tagType(),
+		"tag":       d.tag(),
+	}
+}
+
+// yearRange 返回 2008-2013 格式的年份范围，没有结束年份或与开始年份相同时只返回开始年份
+func (d NameData) yearRange() string {
+	if d.EndYear == "" || d.EndYear == d.Year || d.Year == "" {
+		return d.Year
+	}
+	return d.Year + "-" + d.EndYear
+}
+
+// trimTitleYear 去掉标题末尾与year相同的年份（如部分翻拍作品的TMDB标题 Title 2021、Title (2021)），
+// 避免模板中的{year}再输出一次；年份需与标题其余部分以分隔符隔开，去掉后标题为空时保持原样
+func trimTitleYear(title, year string) string {
+	if year == "" {
+		return title
+	}
+	trimmed := strings.TrimRight(title, " ")
+	for _, suffix := range []string{"(" + year + ")", "（" + year + "）", "[" + year + "]", year} {
+		rest, ok := strings.CutSuffix(trimmed, suffix)
+		if !ok {
+			continue
+		}
+		stripped := strings.TrimRight(rest, " ._-")
+		if stripped != "" && (len(stripped) < len(rest) || suffix != year) {
+			return stripped
+		}
+	}
+	return title
+}
+
+// pathSafe 将标题中的路径分隔符替换为“-”（如 Face/Off 输出 Face-Off），
+// 模板中的“/”仍用于生成子目录，但标题本身不会意外地拆分出一级目录
+func pathSafe(s string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(s)
+}
+
+// windowsReserved Windows中不能用作文件名的设备名（不区分大小写，带扩展名时同样不能使用）
+var windowsReserved = regexp.MustCompile(`(?i)^(?:CON|PRN|AUX|NUL|COM[1-9]|LPT[1-9])$`)
+
+// avoidReserved 路径中的一级名称在第一个“.”之前的部分（去掉末尾空格后）是Windows设备名时，在其后追加“_”，
+// 如 Con.2020.1080p 输出 Con_.2020.1080p，NUL 输出 NUL_，避免在Windows上重命名失败
+func avoidReserved(segment string) string {
+	stem, rest, found := strings.Cut(segment, ".")
+	if !windowsReserved.MatchString(strings.TrimRight(stem, " ")) {
+		return segment
+	}
+	if !found {
+		return stem + "_"
+	}
+	return stem + "_." + rest
+}
+
+// padNumber 将数字补零到width位，width为0或s不是数字（如分组引用 \1）时原样返回
+func padNumber(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%0*d", width, n)
+}
+
+var (
+	placeholderRegex  = regexp.MustCompile(`\{(\w+)\}`)
+	emptyBracketRegex = regexp.MustCompile(`\(\s*\)|\[\s*\]|（\s*）`)
+	spaceDotRegex     = regexp.MustCompile(`\s+\.`)
+	multiSpaceRegex   = regexp.MustCompile(`\s{2,}`)
+	multiDotRegex     = regexp.MustCompile(`\.{2,}`)
+)
+
+// RenderName 按模板生成文件名，未知的占位符保持原样；
+// 空值留下的空括号和多余分隔符会被清理，避免出现 Title..1080p 之类的结果
+func RenderName(template string, data NameData) string {
+	if strings.Contains(template, "{year}") || strings.Contains(template, "{yearRange}") {
+		data.Title = trimTitleYear(data.Title, data.Year)
+	}
+	name := substitute(template, data.values())
+
+	name = emptyBracketRegex.ReplaceAllString(name, "")
+	name = multiSpaceRegex.ReplaceAllString(name, " ")
+	name = spaceDotRegex.ReplaceAllString(name, ".")
+	name = multiDotRegex.ReplaceAllString(name, ".")
+
+	// 模板中的“/”用于生成子目录（如 Season {season}/...），每一级分别清理首尾的分隔符，
+	// 占位符为空导致的空目录层级会被去掉
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		if segment = strings.Trim(segment, ". _-"); segment != "" {
+			segments = append(segments, avoidReserved(segment))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// substitute 替换模板中的占位符，替换结果不会再次展开
+func substitute(template string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(m string) string {
+		if v, ok := values[m[1:len(m)-1]]; ok {
+			return v
+		}
+		return m
+	})
+}
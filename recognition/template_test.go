@@ -0,0 +1,233 @@
+package recognition
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderNameBitDepth(t *testing.T) {
+	data := NameData{Title: "Show", Year: "2020", MediaType: "tv", TMDBID: 1}
+	data = data.WithFileInfo(ParseFileName("Show.S01E01.1080p.10bit.HEVC.mkv"))
+
+	got := RenderName("{title}.S{season}E{episode}.{format}.{bitdepth}.{tag}", data)
+	want := "Show.S01E01.1080p.10bit.{[tmdbid=1;type=tv]}"
+	if got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+
+	data.BitDepth = ""
+	got = RenderName("{title}.S{season}E{episode}.{format}.{bitdepth}.{tag}", data)
+	want = "Show.S01E01.1080p.{[tmdbid=1;type=tv]}"
+	if got != want {
+		t.Errorf("RenderName() without bit depth = %q, want %q", got, want)
+	}
+}
+
+func TestRenderNamePresets(t *testing.T) {
+	data := NameData{Title: "Show", Year: "2020", Season: "01", Episode: "02", MediaType: "tv", TMDBID: 7}
+
+	tests := []struct {
+		preset string
+		want   string
+	}{
+		{"jellyfin", "Show (2020) S01E02 [tmdbid-7]"},
+		{"emby", "Show (2020) - S01E02 [tmdbid=7]"},
+		{"plex", "Show (2020) - s01e02 {tmdb-7}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			p := Presets[tt.preset]
+			d := data
+			d.TagFormat = p.TagFormat
+			if got := RenderName(p.TVTemplate, d); got != tt.want {
+				t.Errorf("RenderName() = %q, want %q", got, tt.want)
+			}
+
+			d.Year = ""
+			if got := RenderName(p.TVTemplate, d); strings.Contains(got, "()") || strings.Contains(got, "  ") {
+				t.Errorf("RenderName() without year = %q, want no empty brackets or double spaces", got)
+			}
+		})
+	}
+}
+
+func TestRenderNameEmptyYear(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     NameData
+		want     string
+	}{
+		{
+			name:     "movie",
+			template: DefaultMovieTemplate,
+			data:     NameData{Title: "Movie", Format: "1080p", MediaType: "movie", TMDBID: 1},
+			want:     "Movie.1080p.{[tmdbid=1;type=movie]}",
+		},
+		{
+			name:     "tv",
+			template: DefaultTVTemplate,
+			data:     NameData{Title: "Show", Season: "01", Episode: "02", Format: "1080p", MediaType: "tv", TMDBID: 2},
+			want:     "Show.S01E02.1080p.{[tmdbid=2;type=tv]}",
+		},
+		{
+			name:     "batch",
+			template: DefaultTVTemplate,
+			data:     NameData{Title: "Show", Season: `\1`, Episode: `\2`, Format: "1080P", MediaType: "tv", TMDBID: 2},
+			want:     `Show.S\1E\2.1080P.{[tmdbid=2;type=tv]}`,
+		},
+		{
+			name:     "no format",
+			template: DefaultMovieTemplate,
+			data:     NameData{Title: "Movie", MediaType: "movie", TMDBID: 1},
+			want:     "Movie.{[tmdbid=1;type=movie]}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderName(tt.template, tt.data); got != tt.want {
+				t.Errorf("RenderName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderNameSubdirectory(t *testing.T) {
+	data := NameData{Title: "Show", Season: "01", Episode: "02", MediaType: "tv", TMDBID: 3}
+	got := RenderName("Season {season}/{title}.{year}.S{season}E{episode}.{tag}", data)
+	want := "Season 01/Show.S01E02.{[tmdbid=3;type=tv]}"
+	if got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+
+	// 为空的占位符不会留下空的目录层级
+	got = RenderName("{status}/{title}.S{season}E{episode}", data)
+	if want := "Show.S01E02"; got != want {
+		t.Errorf("RenderName() with empty status = %q, want %q", got, want)
+	}
+}
+
+func TestRenderNameTitleSlash(t *testing.T) {
+	data := NameData{Title: "Face/Off", Year: "1997", MediaType: "movie", TMDBID: 754}
+	if got, want := RenderName("{title} ({year})/{title}.{year}.{tag}", data), "Face-Off (1997)/Face-Off.1997.{[tmdbid=754;type=movie]}"; got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderNameWindowsReserved(t *testing.T) {
+	tests := []struct {
+		template string
+		title    string
+		want     string
+	}{
+		{"{title}.{year}", "Con", "Con_.2020"},
+		{"{title}", "nul", "nul_"},
+		{"{title} ({year})/{title}.{year}", "AUX", "AUX (2020)/AUX_.2020"},
+		{"{title}.{year}", "COM1", "COM1_.2020"},
+		{"{title}.{year}", "Console", "Console.2020"},
+		{"{title}.{year}", "COM0", "COM0.2020"},
+	}
+	for _, tt := range tests {
+		data := NameData{Title: tt.title, Year: "2020"}
+		if got := RenderName(tt.template, data); got != tt.want {
+			t.Errorf("RenderName(%q) with title %q = %q, want %q", tt.template, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestRenderNameTagType(t *testing.T) {
+	data := NameData{Title: "Doc", Year: "2020", MediaType: "tv", TagType: "movie", TMDBID: 9}
+	if got, want := RenderName(DefaultMovieTemplate, data), "Doc.2020.{[tmdbid=9;type=movie]}"; got != want {
+		t.Errorf("RenderName() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderNamePadding(t *testing.T) {
+	data := NameData{Title: "Show", Season: "01", Episode: "05", MediaType: "tv", TMDBID: 1}
+	template := "{title}.S{season}E{episode}"
+
+	tests := []struct {
+		seasonPad, episodePad int
+		want                  string
+	}{
+		{0, 0, "Show.S01E05"},
+		{1, 1, "Show.S1E5"},
+		{2, 3, "Show.S01E005"},
+	}
+	for _, tt := range tests {
+		d := data
+		d.SeasonPad, d.EpisodePad = tt.seasonPad, tt.episodePad
+		if got := RenderName(template, d); got != tt.want {
+			t.Errorf("RenderName() with pad %d/%d = %q, want %q", tt.seasonPad, tt.episodePad, got, tt.want)
+		}
+	}
+
+	// 批量规则中的分组引用不受影响
+	d := data
+	d.Season, d.Episode, d.EpisodePad = `\1`, `\2`, 3
+	if got := RenderName(template, d); got != `Show.S\1E\2` {
+		t.Errorf("RenderName() with backrefs = %q", got)
+	}
+}
+
+func TestRenderNameYearRange(t *testing.T) {
+	tests := []struct {
+		year, endYear, want string
+	}{
+		{"2008", "2013", "Breaking Bad (2008-2013)"},
+		{"2008", "", "Breaking Bad (2008)"},
+		{"2019", "2019", "Breaking Bad (2019)"},
+		{"", "2013", "Breaking Bad"},
+	}
+	for _, tt := range tests {
+		data := NameData{Title: "Breaking Bad", Year: tt.year, EndYear: tt.endYear}
+		if got := RenderName("{title} ({yearRange})", data); got != tt.want {
+			t.Errorf("RenderName() with %q-%q = %q, want %q", tt.year, tt.endYear, got, tt.want)
+		}
+	}
+}
+
+func TestRenderNameTitleWithYear(t *testing.T) {
+	tests := []struct {
+		title, template, want string
+	}{
+		{"Title 2021", "{title}.{year}.{format}", "Title.2021.1080p"},
+		{"Title (2021)", "{title} ({year})", "Title (2021)"},
+		{"Title 2021", "{title}.S01E01", "Title 2021.S01E01"},
+		{"Title2021", "{title}.{year}", "Title2021.2021"},
+		{"Blade Runner 2049", "{title}.{year}", "Blade Runner 2049.2021"},
+		{"2021", "{title}.{year}", "2021.2021"},
+	}
+	for _, tt := range tests {
+		data := NameData{Title: tt.title, Year: "2021", Format: "1080p", MediaType: "movie"}
+		if got := RenderName(tt.template, data); got != tt.want {
+			t.Errorf("RenderName(%q) with title %q = %q, want %q", tt.template, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizeEpisode(t *testing.T) {
+	data := NameData{Title: "诛仙", Year: "2022", Season: "1", Episode: "2", Format: "1080p", MediaType: "tv", TMDBID: 7, SeasonPad: 2, EpisodePad: 2}
+
+	tests := []struct {
+		template, want string
+	}{
+		{DefaultTVTemplate, "诛仙.2022.第01季第02集.1080p.{[tmdbid=7;type=tv]}"},
+		{Presets["plex"].TVTemplate, "诛仙 (2022) - 第01季第02集 {[tmdbid=7;type=tv]}"},
+		{Presets["chinese"].TVTemplate, "诛仙.2022.第01季第02集.1080p.{[tmdbid=7;type=tv]}"},
+		{DefaultMovieTemplate, "诛仙.2022.1080p.{[tmdbid=7;type=tv]}"},
+	}
+	for _, tt := range tests {
+		if got := RenderName(LocalizeEpisode(tt.template), data); got != tt.want {
+			t.Errorf("RenderName(LocalizeEpisode(%q)) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+
+	// 批量规则的分组引用同样可以使用
+	data.Season, data.Episode = `\1`, `\2`
+	if got := RenderName(LocalizeEpisode(DefaultTVTemplate), data); got != `诛仙.2022.第\1季第\2集.1080p.{[tmdbid=7;type=tv]}` {
+		t.Errorf("RenderName() with backrefs = %q", got)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// renameItem 单个文件的重命名计划
+type renameItem struct {
+	OldPath string
+	NewPath string
+	Moved   bool // applyRenames执行成功，NewPath为文件实际所在的位置（追加序号时会更新）
+}
+
+// fileError 处理单个文件时出现的错误，收集后统一汇报，不中断其余文件
+type fileError struct {
+	Path string
+	Err  error
+}
+
+// renameOptions 生成重命名计划所需的参数
+type renameOptions struct {
+	Template string
+	Data     recognition.NameData
+	Fallback recognition.FileInfo // 提供文件名中缺失的视频格式等信息
+	Parse    recognition.ParseOptions
+	Sidecars bool   // 同时重命名与视频同名的元数据文件（如 .nfo）
+	Dest     string // 目标目录，为空时在原目录中重命名
+}
+
+// sidecarExts 随视频一起重命名的元数据文件扩展名，文件内容保持不变
+var sidecarExts = []string{".nfo"}
+
+func isSidecar(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	for _, e := range sidecarExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// planRenames 为每个匹配文件计算新文件名；多个文件的新文件名相同时只保留第一个，其余的作为错误返回，
+// 避免执行时后一个文件覆盖前一个；返回的计划按orderRenames排序
+func planRenames(files []string, opts renameOptions) ([]renameItem, []fileError) {
+	var items []renameItem
+	var errs []fileError
+	targets := make(map[string]string)
+	add := func(oldPath, newPath string) bool {
+		target := filepath.Clean(newPath)
+		if other, ok := targets[target]; ok {
+			errs = append(errs, fileError{Path: oldPath, Err: fmt.Errorf("目标文件与 %s 相同: %s", other, newPath)})
+			return false
+		}
+		targets[target] = oldPath
+		items = append(items, renameItem{OldPath: oldPath, NewPath: newPath})
+		return true
+	}
+	for _, file := range files {
+		// 元数据文件跟随对应的视频文件一起处理
+		if opts.Sidecars && isSidecar(file) {
+			continue
+		}
+
+		info := recognition.ParsePath(file, opts.Parse)
+		if opts.Data.MediaType == tmdb.MediaTypeTV && info.Episode == "" {
+			errs = append(errs, fileError{Path: file, Err: errors.New("无法从文件名解析出集数")})
+			continue
+		}
+		if info.VideoFormat == "" {
+			info.VideoFormat = opts.Fallback.VideoFormat
+		}
+
+		newBase := recognition.RenderName(opts.Template, opts.Data.WithFileInfo(info))
+		dir := filepath.Dir(file)
+		if opts.Dest != "" {
+			dir = opts.Dest
+		}
+		// 模板中的“/”表示子目录，如 Season {season}/{title}.S{season}E{episode}
+		newBase = filepath.FromSlash(newBase)
+		if !add(file, filepath.Join(dir, newBase+recognition.FileExt(file))) {
+			continue
+		}
+
+		// 同一组分卷共用元数据文件，只跟随第一个分卷处理
+		if _, n := recognition.SplitPart(file); opts.Sidecars && n <= 1 {
+			oldBase := strings.TrimSuffix(file, recognition.FileExt(file))
+			for _, ext := range sidecarExts {
+				if _, err := os.Stat(oldBase + ext); err == nil {
+					add(oldBase+ext, filepath.Join(dir, newBase+ext))
+				}
+			}
+		}
+	}
+	items, cycleErrs := orderRenames(items)
+	return items, append(errs, cycleErrs...)
+}
+
+// orderRenames 调整执行顺序，目标是另一项源文件的项（如集数偏移后 E01->E02、E02->E03）排在该项之后，
+// 使链条的最后一环先移动，避免覆盖或跳过尚未重命名的源文件；其余的项保持原有顺序。
+// 互为目标的项（如 A->B、B->A）无法排序，作为错误返回
+func orderRenames(items []renameItem) ([]renameItem, []fileError) {
+	sources := make(map[string]int, len(items))
+	for i, item := range items {
+		sources[filepath.Clean(item.OldPath)] = i
+	}
+
+	const (
+		pending = iota
+		visiting
+		done
+	)
+	state := make([]int, len(items))
+	ordered := make([]renameItem, 0, len(items))
+	var errs []fileError
+	// visit 先放入以当前项的目标为源文件的项，返回当前项是否处于环中
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		switch state[i] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[i] = visiting
+		cycle := false
+		if j, ok := sources[filepath.Clean(items[i].NewPath)]; ok && j != i {
+			cycle = visit(j)
+		}
+		state[i] = done
+		if cycle {
+			errs = append(errs, fileError{Path: items[i].OldPath, Err: fmt.Errorf("与其他文件互为目标，无法确定重命名顺序: %s", items[i].NewPath)})
+		} else {
+			ordered = append(ordered, items[i])
+		}
+		return cycle
+	}
+	for i := range items {
+		visit(i)
+	}
+	return ordered, errs
+}
+
+// 目标文件已存在时的处理策略
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictSuffix    = "suffix"
+)
+
+// conflictDecision 记录一次目标文件冲突的处理结果
+type conflictDecision struct {
+	OldPath string
+	Target  string
+	Mode    string // 采用的处理方式：conflictSkip、conflictOverwrite 或 conflictSuffix
+	Action  string // 用于输出的说明
+	Renamed string // 追加序号后的文件名，其他处理方式时为空
+}
+
+// applyRenames 执行重命名，单个文件失败时记录错误并继续处理其余文件；
+// 目标文件已存在时按onConflict处理，并返回每次冲突的处理结果；成功的项会标记Moved。
+// 目标与源文件是同一个文件（不区分大小写的文件系统中只改变大小写）时不算冲突；
+// 覆盖时由rename直接替换目标文件，移动失败时原有的目标文件保持不变。
+// 目标是另一项尚未移动（被跳过或失败）的源文件时不按onConflict处理，作为错误返回，避免覆盖该文件
+func applyRenames(items []renameItem, onConflict string) ([]fileError, []conflictDecision) {
+	var errs []fileError
+	var conflicts []conflictDecision
+	sources := make(map[string]int, len(items))
+	for i, item := range items {
+		sources[filepath.Clean(item.OldPath)] = i
+	}
+	for i, item := range items {
+		if item.OldPath == item.NewPath {
+			continue
+		}
+
+		target := item.NewPath
+		if j, ok := sources[filepath.Clean(target)]; ok && j != i && !items[j].Moved {
+			errs = append(errs, fileError{Path: item.OldPath, Err: fmt.Errorf("目标文件 %s 尚未重命名，未执行", target)})
+			continue
+		}
+		if stat, err := os.Stat(target); err == nil && !sameFile(item.OldPath, stat) {
+			decision := conflictDecision{OldPath: item.OldPath, Target: target}
+			switch onConflict {
+			case conflictOverwrite:
+				decision.Mode, decision.Action = conflictOverwrite, "覆盖"
+			case conflictSuffix:
+				target = freeName(target)
+				decision.Mode, decision.Action, decision.Renamed = conflictSuffix, "改名为 "+filepath.Base(target), target
+			default:
+				decision.Mode, decision.Action = conflictSkip, "跳过"
+				conflicts = append(conflicts, decision)
+				continue
+			}
+			conflicts = append(conflicts, decision)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			errs = append(errs, fileError{Path: item.OldPath, Err: fmt.Errorf("创建目录失败: %v", err)})
+			continue
+		}
+		if err := moveFile(item.OldPath, target); err != nil {
+			errs = append(errs, fileError{Path: item.OldPath, Err: err})
+			continue
+		}
+		items[i].NewPath, items[i].Moved = target, true
+		fmt.Printf("已重命名: %s -> %s\n", item.OldPath, target)
+	}
+	return errs, conflicts
+}
+
+// sameFile 判断path与已存在的目标文件是否为同一个文件
+func sameFile(path string, target os.FileInfo) bool {
+	stat, err := os.Stat(path)
+	return err == nil && os.SameFile(stat, target)
+}
+
+// finalPath 返回已执行成功的重命名项中文件实际所在位置的绝对路径，未执行时返回空字符串
+func finalPath(item renameItem) string {
+	if !item.Moved {
+		return ""
+	}
+	if abs, err := filepath.Abs(item.NewPath); err == nil {
+		return abs
+	}
+	return item.NewPath
+}
+
+// printFinalPaths 输出重命名后文件所在位置的绝对路径，每行一个，便于后续步骤读取
+func printFinalPaths(items []renameItem) {
+	fmt.Println("\n=== 重命名后的文件 ===")
+	for _, item := range items {
+		if path := finalPath(item); path != "" {
+			fmt.Println(path)
+		}
+	}
+}
+
+// freeName 在文件名后追加 (1)、(2)... 直到找到一个不存在的文件名
+func freeName(path string) string {
+	ext := recognition.FileExt(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// printConflictReport 输出目标文件冲突的处理汇总
+func printConflictReport(conflicts []conflictDecision) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Printf("\n=== 冲突处理（%d个文件） ===\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("%s -> %s: %s\n", c.OldPath, c.Target, c.Action)
+	}
+}
+
+// rename 即os.Rename，测试中替换为返回EXDEV的函数以模拟跨文件系统移动
+var rename = os.Rename
+
+// moveFile 移动文件，dst已存在时被替换；跨文件系统时（os.Rename返回EXDEV）改为先复制到目标目录中的临时文件，
+// 再替换dst并删除源文件，复制失败时dst保持不变
+func moveFile(src, dst string) error {
+	err := rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".partial")
+	if err := copyFile(src, tmp); err != nil {
+		return fmt.Errorf("跨文件系统复制失败: %v", err)
+	}
+	if err := rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile 复制文件内容、权限和修改时间，失败时删除未完成的目标文件
+func copyFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(dst)
+		}
+	}()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, stat.ModTime(), stat.ModTime())
+}
+
+// printErrorReport 输出所有文件错误的汇总
+func printErrorReport(errs []fileError) {
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Printf("\n=== 错误汇总（%d个文件） ===\n", len(errs))
+	for _, e := range errs {
+		fmt.Printf("%s: %v\n", e.Path, e.Err)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// writeFile 创建内容为content的文件，所在的目录会一并创建
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile 返回文件内容，文件不存在时返回空字符串
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPlanRenamesDuplicateTargets(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		filepath.Join(dir, "Show.S01E01.1080p.mkv"),
+		filepath.Join(dir, "Show.S01E01.720p.mkv"),
+		filepath.Join(dir, "Show.S01E02.1080p.mkv"),
+	}
+	items, errs := planRenames(files, renameOptions{
+		Template: "{title} S{season}E{episode}",
+		Data:     recognition.NameData{Title: "Show", MediaType: tmdb.MediaTypeTV},
+	})
+	if len(items) != 2 || items[0].OldPath != files[0] || items[1].OldPath != files[2] {
+		t.Errorf("planRenames() items = %+v, want %s and %s", items, files[0], files[2])
+	}
+	if len(errs) != 1 || errs[0].Path != files[1] || !strings.Contains(errs[0].Err.Error(), files[0]) {
+		t.Errorf("planRenames() errors = %+v, want duplicate target error for %s", errs, files[1])
+	}
+}
+
+func TestApplyRenamesConflicts(t *testing.T) {
+	tests := []struct {
+		onConflict string
+		want       map[string]string // 执行后各文件的内容，空字符串表示文件不存在
+		action     string
+	}{
+		{conflictSkip, map[string]string{"a.mkv": "new", "b.mkv": "old", "b (1).mkv": ""}, conflictSkip},
+		{conflictOverwrite, map[string]string{"a.mkv": "", "b.mkv": "new", "b (1).mkv": ""}, conflictOverwrite},
+		{conflictSuffix, map[string]string{"a.mkv": "", "b.mkv": "old", "b (1).mkv": "new"}, conflictSuffix},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "a.mkv"), "new")
+		writeFile(t, filepath.Join(dir, "b.mkv"), "old")
+		items := []renameItem{{OldPath: filepath.Join(dir, "a.mkv"), NewPath: filepath.Join(dir, "b.mkv")}}
+
+		errs, conflicts := applyRenames(items, tt.onConflict)
+		if len(errs) > 0 {
+			t.Errorf("applyRenames(%s) errors = %+v", tt.onConflict, errs)
+		}
+		if len(conflicts) != 1 || conflicts[0].Mode != tt.action {
+			t.Errorf("applyRenames(%s) conflicts = %+v, want one %s", tt.onConflict, conflicts, tt.action)
+		}
+		for name, want := range tt.want {
+			if got := readFile(t, filepath.Join(dir, name)); got != want {
+				t.Errorf("applyRenames(%s): %s = %q, want %q", tt.onConflict, name, got, want)
+			}
+		}
+	}
+}
+
+func TestApplyRenamesSameFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "show.mkv")
+	writeFile(t, src, "data")
+	// 指向同一个文件的硬链接，相当于不区分大小写的文件系统中只改变大小写的重命名
+	target := filepath.Join(dir, "Show.mkv")
+	if err := os.Link(src, target); err != nil {
+		t.Skipf("无法创建硬链接: %v", err)
+	}
+
+	items := []renameItem{{OldPath: src, NewPath: target}}
+	errs, conflicts := applyRenames(items, conflictOverwrite)
+	if len(errs) > 0 || len(conflicts) > 0 {
+		t.Errorf("applyRenames() = %+v, %+v, want no errors or conflicts", errs, conflicts)
+	}
+	if got := readFile(t, target); got != "data" {
+		t.Errorf("applyRenames(): %s = %q, want %q", target, got, "data")
+	}
+}
+
+func TestOrderRenamesChain(t *testing.T) {
+	items := []renameItem{
+		{OldPath: "a.mkv", NewPath: "b.mkv"},
+		{OldPath: "b.mkv", NewPath: "c.mkv"},
+		{OldPath: "x.mkv", NewPath: "y.mkv"},
+		{OldPath: "c.mkv", NewPath: "d.mkv"},
+		{OldPath: "p.mkv", NewPath: "q.mkv"},
+		{OldPath: "q.mkv", NewPath: "p.mkv"},
+	}
+	ordered, errs := orderRenames(items)
+	var got []string
+	for _, item := range ordered {
+		got = append(got, item.OldPath)
+	}
+	if want := "c.mkv b.mkv a.mkv x.mkv"; strings.Join(got, " ") != want {
+		t.Errorf("orderRenames() order = %v, want %s", got, want)
+	}
+	if len(errs) != 2 {
+		t.Errorf("orderRenames() errors = %+v, want the p/q cycle", errs)
+	}
+}
+
+func TestApplyRenamesChain(t *testing.T) {
+	for _, onConflict := range []string{conflictSkip, conflictOverwrite, conflictSuffix} {
+		dir := t.TempDir()
+		path := func(name string) string { return filepath.Join(dir, name) }
+		writeFile(t, path("a.mkv"), "a")
+		writeFile(t, path("b.mkv"), "b")
+		// 集数偏移后 A->B、B->C，按原有顺序执行时A会覆盖或因冲突跳过尚未移动的B
+		items, errs := orderRenames([]renameItem{
+			{OldPath: path("a.mkv"), NewPath: path("b.mkv")},
+			{OldPath: path("b.mkv"), NewPath: path("c.mkv")},
+		})
+		if len(errs) > 0 {
+			t.Fatalf("orderRenames() errors = %+v", errs)
+		}
+
+		applyErrs, conflicts := applyRenames(items, onConflict)
+		if len(applyErrs) > 0 || len(conflicts) > 0 {
+			t.Errorf("applyRenames(%s) = %+v, %+v, want no errors or conflicts", onConflict, applyErrs, conflicts)
+		}
+		for name, want := range map[string]string{"a.mkv": "", "b.mkv": "a", "c.mkv": "b"} {
+			if got := readFile(t, path(name)); got != want {
+				t.Errorf("applyRenames(%s): %s = %q, want %q", onConflict, name, got, want)
+			}
+		}
+	}
+}
+
+func TestApplyRenamesChainBlocked(t *testing.T) {
+	for _, onConflict := range []string{conflictSkip, conflictOverwrite} {
+		dir := t.TempDir()
+		path := func(name string) string { return filepath.Join(dir, name) }
+		writeFile(t, path("a.mkv"), "a")
+		writeFile(t, path("b.mkv"), "b")
+		// 目标C是非空目录，B->C 跳过或移动失败，A->B 不能覆盖仍在原处的B
+		writeFile(t, path("c.mkv/keep"), "")
+		items := []renameItem{
+			{OldPath: path("b.mkv"), NewPath: path("c.mkv")},
+			{OldPath: path("a.mkv"), NewPath: path("b.mkv")},
+		}
+
+		errs, _ := applyRenames(items, onConflict)
+		if len(errs) == 0 || errs[len(errs)-1].Path != path("a.mkv") {
+			t.Errorf("applyRenames(%s) errors = %+v, want an error for %s", onConflict, errs, path("a.mkv"))
+		}
+		for name, want := range map[string]string{"a.mkv": "a", "b.mkv": "b"} {
+			if got := readFile(t, path(name)); got != want {
+				t.Errorf("applyRenames(%s): %s = %q, want %q", onConflict, name, got, want)
+			}
+		}
+	}
+}
+
+// crossDevice 让从src开始的移动返回EXDEV，模拟跨文件系统的移动，其余的重命名不受影响
+func crossDevice(t *testing.T, src string) {
+	t.Helper()
+	rename = func(oldPath, newPath string) error {
+		if oldPath == src {
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: syscall.EXDEV}
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestMoveFileCrossDevice(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "a.mkv")
+	dst := filepath.Join(dir, "dst", "b.mkv")
+	writeFile(t, src, "data")
+	writeFile(t, dst, "old")
+	if err := os.Chmod(src, 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	crossDevice(t, src)
+
+	if err := moveFile(src, dst); err != nil {
+		t.Fatalf("moveFile() error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("moveFile() should remove the source, Stat() error = %v", err)
+	}
+	if got := readFile(t, dst); got != "data" {
+		t.Errorf("moveFile(): %s = %q, want %q", dst, got, "data")
+	}
+	stat, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0600 || !stat.ModTime().Equal(modTime) {
+		t.Errorf("moveFile() mode, mtime = %v, %v, want %v, %v", stat.Mode().Perm(), stat.ModTime(), os.FileMode(0600), modTime)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 1 {
+		t.Errorf("moveFile() left temporary files: %v", entries)
+	}
+}
+
+func TestMoveFileCrossDeviceFailure(t *testing.T) {
+	dir := t.TempDir()
+	// 源路径是目录时复制内容会失败
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst", "b.mkv")
+	writeFile(t, dst, "old")
+	crossDevice(t, src)
+
+	if err := moveFile(src, dst); err == nil {
+		t.Fatal("moveFile() error = nil, want copy error")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("moveFile() should keep the source, Stat() error = %v", err)
+	}
+	if got := readFile(t, dst); got != "old" {
+		t.Errorf("moveFile(): %s = %q, want the existing target to be kept", dst, got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 1 {
+		t.Errorf("moveFile() left temporary files: %v", entries)
+	}
+}
+
+func TestApplyRenamesCrossDevice(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "Show.S01E01.mkv")
+	writeFile(t, src, "data")
+	crossDevice(t, src)
+
+	items := []renameItem{{OldPath: src, NewPath: filepath.Join(dir, "dest", "Season 01", "Show S01E01.mkv")}}
+	errs, _ := applyRenames(items, conflictSkip)
+	if len(errs) > 0 {
+		t.Fatalf("applyRenames() errors = %+v", errs)
+	}
+	if !items[0].Moved || readFile(t, items[0].NewPath) != "data" {
+		t.Errorf("applyRenames() = %+v, want the file moved to the new directory", items[0])
+	}
+}
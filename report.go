@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// report -json 输出的结果
+type report struct {
+	MediaType      string           `json:"media_type"`
+	TMDBID         int              `json:"tmdb_id"`
+	Title          string           `json:"title"`
+	Year           string           `json:"year"`
+	Status         string           `json:"status,omitempty"`
+	Provider       string           `json:"provider,omitempty"` // -providers 查询到的流媒体服务
+	Adult          bool             `json:"adult,omitempty"`
+	Files          []string         `json:"files"`
+	Rule           *rule            `json:"rule,omitempty"`
+	BatchRule      *rule            `json:"batch_rule,omitempty"`
+	FileRules      []rule           `json:"file_rules,omitempty"`
+	SeasonRules    []seasonRule     `json:"season_rules,omitempty"`
+	UnmatchedFiles []string         `json:"unmatched_files"`
+	Unresolved     []string         `json:"unresolved_files,omitempty"` // -no-default-season 排除的无法确定季数的文件
+	Duplicates     []string         `json:"duplicate_files,omitempty"`  // -dedupe 跳过的重复剧集
+	Renames        []renameReport   `json:"renames,omitempty"`
+	Conflicts      []conflictReport `json:"conflicts,omitempty"` // -apply 时目标文件已存在的处理结果
+	Errors         []errorReport    `json:"errors,omitempty"`
+	NotFound       []string         `json:"not_found,omitempty"` // TMDB返回404的请求路径，如 /tv/1/season/3
+}
+
+type renameReport struct {
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	Final string `json:"final,omitempty"` // 执行成功后文件实际所在位置的绝对路径，未执行或失败时为空
+}
+
+// conflictReport 一次目标文件冲突的处理结果，action 为 skip、overwrite 或 suffix
+type conflictReport struct {
+	Old    string `json:"old"`
+	Target string `json:"target"`
+	Action string `json:"action"`
+	Final  string `json:"final,omitempty"` // 追加序号后的实际位置
+}
+
+type errorReport struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+func (r *report) addRenames(items []renameItem) {
+	for _, item := range items {
+		r.Renames = append(r.Renames, renameReport{Old: item.OldPath, New: item.NewPath, Final: finalPath(item)})
+	}
+}
+
+func (r *report) addConflicts(conflicts []conflictDecision) {
+	for _, c := range conflicts {
+		r.Conflicts = append(r.Conflicts, conflictReport{Old: c.OldPath, Target: c.Target, Action: c.Mode, Final: c.Renamed})
+	}
+}
+
+func (r *report) addErrors(errs []fileError) {
+	for _, e := range errs {
+		r.Errors = append(r.Errors, errorReport{Path: e.Path, Error: e.Err.Error()})
+	}
+}
+
+// writeReport 将结果以JSON格式写入文件
+func writeReport(path string, r *report) error {
+	data, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
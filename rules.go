@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// rule 一条正则替换规则
+type rule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+func showRegexRules(originalName, fixedTitle, template string, info recognition.FileInfo, data recognition.NameData, opts recognition.PatternOptions) rule {
+	fmt.Println("\n=== 正则替换规则 ===")
+	fmt.Println("原始文件名:\n", originalName)
+	fmt.Println("\n要替换成:")
+
+	data = data.WithFileInfo(info)
+
+	// 电影、未指定标题以及 -whole-name 时，直接以完整文件名作为被替换词
+	if data.MediaType == tmdb.MediaTypeMovie || fixedTitle == "" || opts.WholeName {
+		finalName := recognition.RenderName(template, data)
+		fmt.Println(finalName)
+
+		pattern := recognition.QuoteFileName(originalName)
+		if opts.WholeName {
+			pattern = recognition.QuoteWholeName(originalName)
+		}
+		data.Title = opts.EscapeReplacement(data.Title)
+		replacement := recognition.RenderName(template, data)
+
+		fmt.Println()
+		fmt.Printf("被替换词: \n%s\n", pattern)
+		fmt.Printf("替换词: \n%s\n", replacement)
+		return rule{Pattern: pattern, Replacement: replacement}
+	}
+
+	if opts.KeepBetween {
+		data.Between = recognition.BetweenText(originalName, fixedTitle)
+	}
+	finalName := recognition.RenderName(template, data)
+	fmt.Println(finalName)
+
+	// 构建正则表达式模式
+	pattern := recognition.BuildBatchMatchPattern(fixedTitle, opts)
+
+	data.Title = opts.EscapeReplacement(data.Title)
+	data.Season, data.Episode, data.Between = opts.References()
+	replacement := recognition.RenderName(template, data)
+
+	fmt.Println()
+	fmt.Printf("被替换词: \n%s\n", pattern)
+	fmt.Printf("替换词: \n%s\n", replacement)
+	return rule{Pattern: pattern, Replacement: replacement}
+}
+
+func showBatchRegexRules(prefix, suffix, fixedTitle, template, videoFormat string, info recognition.FileInfo, data recognition.NameData, opts recognition.PatternOptions) rule {
+	fmt.Println("\n=== 批量正则替换规则 ===")
+
+	// 构建匹配模式
+	matchPattern := recognition.BuildBatchMatchPattern(fixedTitle, opts)
+
+	fmt.Printf("匹配模式: \n%s\n\n", matchPattern)
+
+	// 构建替换模式
+	data = data.WithFileInfo(info)
+	data.Title = opts.EscapeReplacement(data.Title)
+	data.Season, data.Episode, data.Between = opts.References()
+	data.Format = videoFormat
+	replacePattern := recognition.RenderName(template, data)
+	fmt.Printf("替换为: \n%s\n", replacePattern)
+
+	fmt.Println("\n使用说明:")
+	fmt.Println("1. 使用上述正则表达式可以匹配目录下所有相关剧集文件")
+	season, episode, between := opts.References()
+	if opts.KeepBetween {
+		fmt.Printf("2. %s 表示标题与季集之间的文本，%s 表示季数，%s 表示集数\n", between, season, episode)
+	} else {
+		fmt.Printf("2. %s 表示季数，%s 表示集数\n", season, episode)
+	}
+	fmt.Println("3. 视频格式会保持文件原有的格式")
+	return rule{Pattern: matchPattern, Replacement: replacePattern}
+}
+
+var backrefRegex = regexp.MustCompile(`\\(\d)`)
+
+// previewBatchRule 将批量规则应用到每个匹配的文件名上，输出替换结果，并标记规则无法匹配的文件
+func previewBatchRule(files []string, r rule, opts recognition.PatternOptions) {
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		warnf("批量匹配模式无法编译：%v", err)
+		return
+	}
+	// \1 形式的分组引用转换为Go的 ${1}，其余的$按字面处理；${1} 形式的替换词与Go的语法相同，直接使用
+	replacement := r.Replacement
+	if opts.BackrefStyle != recognition.BackrefDollar {
+		replacement = backrefRegex.ReplaceAllString(strings.ReplaceAll(r.Replacement, "$", "$$"), "$${$1}")
+	}
+
+	fmt.Println("\n=== 批量规则预览 ===")
+	failed := 0
+	for _, file := range files {
+		name := filepath.Base(file)
+		if !re.MatchString(name) {
+			fmt.Printf("[未匹配] %s\n", name)
+			failed++
+			continue
+		}
+		fmt.Printf("%s -> %s\n", name, re.ReplaceAllString(name, replacement))
+	}
+	if failed > 0 {
+		warnf("批量规则无法匹配其中%d个文件，请检查标题固定部分或改用 -apply", failed)
+	}
+}
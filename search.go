@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// candidateTitle 返回搜索结果的标题和年份，电影和电视剧使用不同的字段
+func candidateTitle(c tmdb.MovieResponse) (string, string) {
+	if c.Title != "" {
+		return c.Title, tmdb.GetYear(c.ReleaseDate)
+	}
+	return c.Name, tmdb.GetYear(c.FirstAirDate)
+}
+
+// pickCandidate 按热度和与文件名中年份year（0表示没有）的接近程度排序后列出搜索结果供选择，
+// 输入 d N 可先查看第N个候选的简介、年份和海报路径；autoPick时直接选择排在第一的候选。
+// 返回选中的TMDB ID，直接回车或没有结果时返回0
+func pickCandidate(ctx context.Context, timeout time.Duration, client *tmdb.Client, mediaType string, results []tmdb.MovieResponse, year int, autoPick bool) int {
+	if len(results) == 0 {
+		fmt.Println("没有找到搜索结果")
+		return 0
+	}
+
+	ranked := tmdb.RankResults(results, year)
+	results = make([]tmdb.MovieResponse, len(ranked))
+	if year > 0 {
+		fmt.Printf("\n搜索结果（按热度和与文件名中年份 %d 的接近程度排序）：\n", year)
+	} else {
+		fmt.Println("\n搜索结果（文件名中没有年份，按热度排序）：")
+	}
+	for i, c := range ranked {
+		results[i] = c.MovieResponse
+		title, y := candidateTitle(c.MovieResponse)
+		fmt.Printf("%d. %s (%s) [tmdbid=%d] 得分 %.2f（%s）\n", i+1, title, y, c.ID, c.Score, scoreDetail(c))
+	}
+	if autoPick {
+		title, y := candidateTitle(results[0])
+		infof("已自动选择排在第一的候选: %s (%s) [tmdbid=%d]", title, y, results[0].ID)
+		return results[0].ID
+	}
+
+	for {
+		input := requireInput("请输入序号选择，输入 d 序号 查看详情（直接回车退出）: ")
+		if input == "" {
+			return 0
+		}
+
+		detail := false
+		if rest, ok := strings.CutPrefix(input, "d"); ok {
+			detail = true
+			input = strings.TrimSpace(rest)
+		}
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(results) {
+			fmt.Printf("无效的序号，请输入1到%d之间的数字\n", len(results))
+			continue
+		}
+		if !detail {
+			return results[n-1].ID
+		}
+
+		var movie *tmdb.MovieResponse
+		err = request(ctx, timeout, func(ctx context.Context) (err error) {
+			movie, err = client.Details(ctx, mediaType, results[n-1].ID)
+			return err
+		})
+		if err != nil {
+			warnf("获取详情失败: %v", err)
+			continue
+		}
+		title, year := candidateTitle(*movie)
+		fmt.Printf("\n%s (%s)\n原始标题: %s%s\n海报: %s\n简介: %s\n\n", title, year, movie.OriginalTitle, movie.OriginalName, movie.PosterPath, movie.Overview)
+	}
+}
+
+// scoreDetail 说明候选得分的组成：热度（相对于结果中最高热度的百分比）和与文件名年份相差的年数
+func scoreDetail(c tmdb.ScoredResult) string {
+	detail := fmt.Sprintf("热度 %.0f%%", c.RelativePopularity*100)
+	if c.YearDiff >= 0 {
+		detail += fmt.Sprintf("，年份相差 %d", c.YearDiff)
+	}
+	return detail
+}
+
+// pickInterpretation 按电影和电视剧分别查询同一个TMDB ID，跳过返回404的一种；
+// 两种都存在时列出候选由用户选择
+func pickInterpretation(ctx context.Context, timeout time.Duration, client *tmdb.Client, id int) (string, *tmdb.MovieResponse, error) {
+	type candidate struct {
+		mediaType string
+		label     string
+		resp      *tmdb.MovieResponse
+	}
+	var candidates []candidate
+	for _, c := range []candidate{{mediaType: tmdb.MediaTypeMovie, label: "电影"}, {mediaType: tmdb.MediaTypeTV, label: "电视剧"}} {
+		err := request(ctx, timeout, func(ctx context.Context) (err error) {
+			c.resp, err = client.Details(ctx, c.mediaType, id)
+			return err
+		})
+		var statusErr *tmdb.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", nil, fmt.Errorf("TMDB ID %d 既不是电影也不是电视剧", id)
+	case 1:
+		fmt.Printf("\nTMDB ID %d 只存在%s结果\n", id, candidates[0].label)
+		return candidates[0].mediaType, candidates[0].resp, nil
+	}
+
+	fmt.Printf("\nTMDB ID %d 同时存在电影和电视剧结果：\n", id)
+	for i, c := range candidates {
+		title, year := candidateTitle(*c.resp)
+		fmt.Printf("%d. %s: %s (%s) {[tmdbid=%d;type=%s]}\n", i+1, c.label, title, year, id, c.mediaType)
+	}
+	n, err := getIntInput("请输入选项（1或2）: ")
+	if err != nil || n < 1 || n > len(candidates) {
+		return "", nil, errors.New("无效的选项，程序退出")
+	}
+	return candidates[n-1].mediaType, candidates[n-1].resp, nil
+}
+
+// confirmMetadata 输出查询到的标题、年份和简介，询问是否使用该条目生成规则，直接回车表示确认
+func confirmMetadata(movie *tmdb.MovieResponse) bool {
+	title, year := candidateTitle(*movie)
+	if year == "" {
+		year = "年份未知"
+	}
+	fmt.Printf("\n=== TMDB条目 ===\n%s (%s) [tmdbid=%d]\n", title, year, movie.ID)
+	if movie.Overview != "" {
+		fmt.Printf("简介: %s\n", movie.Overview)
+	}
+	answer := getInput("确认使用该条目生成规则？(Y/n): ")
+	return answer == "" || strings.EqualFold(answer, "y")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+)
+
+// seasonGroup 同一季的文件
+type seasonGroup struct {
+	Season string
+	Files  []string
+}
+
+// seasonRule -all-seasons 模式下某一季的批量规则
+type seasonRule struct {
+	Season string `json:"season"`
+	Rule   rule   `json:"rule"`
+}
+
+// groupBySeason 按季数分组，季数优先取自所在文件夹（Season 2、S02、第二季），文件夹不是季文件夹时使用文件名中识别出的季数；
+// 结果按季数从小到大排列，每季中保持文件原有的顺序
+func groupBySeason(files []string, opts recognition.ParseOptions) []seasonGroup {
+	index := make(map[string]int)
+	var groups []seasonGroup
+	for _, file := range files {
+		season, ok := recognition.ParseSeasonFromDir(filepath.Base(filepath.Dir(file)))
+		if !ok {
+			season = recognition.ParsePath(file, opts).Season
+		}
+		i, ok := index[season]
+		if !ok {
+			i = len(groups)
+			index[season] = i
+			groups = append(groups, seasonGroup{Season: season})
+		}
+		groups[i].Files = append(groups[i].Files, file)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, _ := strconv.Atoi(groups[i].Season)
+		b, _ := strconv.Atoi(groups[j].Season)
+		return a < b
+	})
+	return groups
+}
+
+// showSeasonBatchRules 按季分组输出批量规则和预览，每季的匹配模式只匹配该季，以该季第一个文件（-majority-format 时为该季多数文件）的视频格式为准，TMDB数据在各季之间共用
+func showSeasonBatchRules(groups []seasonGroup, fixedTitle, template string, fallback recognition.FileInfo, data recognition.NameData,
+	opts recognition.PatternOptions, parseOpts recognition.ParseOptions) []seasonRule {
+	var rules []seasonRule
+	for _, group := range groups {
+		season := group.Season
+		if season == "" {
+			season = "?"
+		}
+		fmt.Printf("\n######## 第%s季（%d个文件） ########\n", season, len(group.Files))
+
+		_, suffix, format := recognition.GenerateRegexPattern(group.Files, fixedTitle, opts, parseOpts)
+		if suffix == "" {
+			fmt.Println("该季第一个文件中找不到标题或SxxExx，不生成批量规则，可使用 -apply 按集数直接重命名")
+			continue
+		}
+		info := recognition.ParsePath(group.Files[0], parseOpts)
+		if info.VideoFormat = format; info.VideoFormat == "" {
+			info.VideoFormat = fallback.VideoFormat
+		}
+		seasonOpts := opts
+		if n, err := strconv.Atoi(group.Season); err == nil {
+			seasonOpts.Seasons = []int{n}
+		}
+		r := showBatchRegexRules("", suffix, fixedTitle, template, info.VideoFormat, info, data, seasonOpts)
+		previewBatchRule(group.Files, r, seasonOpts)
+		rules = append(rules, seasonRule{Season: group.Season, Rule: r})
+	}
+	return rules
+}
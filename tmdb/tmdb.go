@@ -0,0 +1,520 @@
+// Package tmdb 封装TMDB接口的请求与响应类型。
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BaseURL TMDB API地址
+const (
+	BaseURL = "https://api.tmdb.org/3"
+)
+
+// 媒体类型，同时也是TMDB接口路径的一部分
+const (
+	MediaTypeMovie = "movie"
+	MediaTypeTV    = "tv"
+)
+
+// MovieResponse 电影或电视剧详情接口的响应
+type MovieResponse struct {
+	Title            string `json:"title"`
+	Name             string `json:"name"`              // 电视剧标题
+	OriginalTitle    string `json:"original_title"`    // 电影原始标题
+	OriginalName     string `json:"original_name"`     // 电视剧原始标题
+	OriginalLanguage string `json:"original_language"` // 原始语言，如 ja、en
+	ReleaseDate      string `json:"release_date"`      // 电影日期
+	FirstAirDate     string `json:"first_air_date"`    // 电视剧日期
+	LastAirDate      string `json:"last_air_date"`     // 电视剧最近一集的播出日期
+	Overview         string `json:"overview"`
+	PosterPath       string `json:"poster_path"`
+	Status           string `json:"status"` // 如 Released、Returning Series、Ended，旧数据可能缺失
+	Adult            bool   `json:"adult"`
+	ID               int    `json:"id"`
+
+	// Popularity TMDB热度，只用于比较搜索结果
+	Popularity float64 `json:"popularity"`
+
+	// ReleaseDates 各地区的上映日期，仅在请求时附加了 release_dates 时存在
+	ReleaseDates *ReleaseDatesResponse `json:"release_dates,omitempty"`
+	// WatchProviders 各地区的观看渠道，仅在请求时附加了 watch/providers 时存在
+	WatchProviders *WatchProvidersResponse `json:"watch/providers,omitempty"`
+}
+
+// WatchProvidersResponse 各地区的观看渠道，键为地区代码，如 US、CN
+type WatchProvidersResponse struct {
+	Results map[string]RegionProviders `json:"results"`
+}
+
+// RegionProviders 一个地区的观看渠道，Flatrate为订阅即可观看的流媒体服务
+type RegionProviders struct {
+	Flatrate []Provider `json:"flatrate"`
+}
+
+// Provider 观看渠道，DisplayPriority越小越靠前
+type Provider struct {
+	ProviderName    string `json:"provider_name"`
+	DisplayPriority int    `json:"display_priority"`
+}
+
+// AppendWatchProviders 附加各地区观看渠道的 append_to_response 参数
+const AppendWatchProviders = "watch/providers"
+
+// StreamingProvider 返回region地区中订阅即可观看的流媒体服务中最靠前的一个，没有附加观看渠道或该地区没有时返回空字符串
+func (m *MovieResponse) StreamingProvider(region string) string {
+	if m.WatchProviders == nil {
+		return ""
+	}
+	providers := m.WatchProviders.Results[strings.ToUpper(region)].Flatrate
+	if len(providers) == 0 {
+		return ""
+	}
+	best := providers[0]
+	for _, p := range providers[1:] {
+		if p.DisplayPriority < best.DisplayPriority {
+			best = p
+		}
+	}
+	return best.ProviderName
+}
+
+// ReleaseDatesResponse 电影各地区的上映日期
+type ReleaseDatesResponse struct {
+	Results []CountryReleaseDates `json:"results"`
+}
+
+// CountryReleaseDates 一个地区的上映日期
+type CountryReleaseDates struct {
+	Country      string        `json:"iso_3166_1"`
+	ReleaseDates []ReleaseDate `json:"release_dates"`
+}
+
+// ReleaseDate 一次上映，Type为TMDB的上映类型
+type ReleaseDate struct {
+	ReleaseDate string `json:"release_date"` // 如 2010-07-15T00:00:00.000Z
+	Type        int    `json:"type"`
+}
+
+// TMDB上映类型
+const (
+	ReleaseTypePremiere   = 1
+	ReleaseTypeLimited    = 2
+	ReleaseTypeTheatrical = 3
+	ReleaseTypeDigital    = 4
+	ReleaseTypePhysical   = 5
+	ReleaseTypeTV         = 6
+)
+
+// AppendReleaseDates 附加各地区上映日期的 append_to_response 参数
+const AppendReleaseDates = "release_dates"
+
+// 确定电影年份所用的日期
+const (
+	YearSourceRelease  = "release"  // 详情中的 release_date（默认）
+	YearSourceDigital  = "digital"  // 各地区中最早的数字发行日期
+	YearSourceEarliest = "earliest" // 各地区、各类型中最早的上映日期
+)
+
+// MovieDate 按source返回电影用于确定年份的日期（2006-01-02 格式）；source为 YearSourceDigital、YearSourceEarliest
+// 而没有附加 release_dates 或其中没有符合的日期时，使用详情中的 release_date
+func (m *MovieResponse) MovieDate(source string) string {
+	if source == YearSourceRelease || m.ReleaseDates == nil {
+		return m.ReleaseDate
+	}
+	earliest := ""
+	for _, country := range m.ReleaseDates.Results {
+		for _, d := range country.ReleaseDates {
+			if source == YearSourceDigital && d.Type != ReleaseTypeDigital {
+				continue
+			}
+			if date, _, _ := strings.Cut(d.ReleaseDate, "T"); date != "" && (earliest == "" || date < earliest) {
+				earliest = date
+			}
+		}
+	}
+	if earliest == "" {
+		return m.ReleaseDate
+	}
+	return earliest
+}
+
+// SearchResponse 搜索接口的响应，结果与详情接口的字段相同
+type SearchResponse struct {
+	Page         int             `json:"page"`
+	TotalResults int             `json:"total_results"`
+	Results      []MovieResponse `json:"results"`
+}
+
+// 搜索结果打分时热度和年份接近程度各自的权重，文件名中没有年份时只按热度排序
+const (
+	popularityWeight = 0.5
+	yearWeight       = 0.5
+)
+
+// ScoredResult 打分后的搜索结果，Score在0到1之间，越大越可能是要找的条目
+type ScoredResult struct {
+	MovieResponse
+	Score              float64
+	RelativePopularity float64 // 热度与结果中最高热度的比值，0到1之间，原始热度为 MovieResponse.Popularity
+	YearDiff           int     // 与文件名中年份相差的年数，无法比较时为-1
+}
+
+// RankResults 按热度和与year（文件名中的年份，0表示没有）的接近程度为搜索结果打分，按分数从高到低排列，
+// 分数相同时保持TMDB返回的顺序；年份相差n年的得分为 1/(n+1)，没有年份的结果不得分
+func RankResults(results []MovieResponse, year int) []ScoredResult {
+	maxPopularity := 0.0
+	for _, r := range results {
+		maxPopularity = max(maxPopularity, r.Popularity)
+	}
+	scored := make([]ScoredResult, len(results))
+	for i, r := range results {
+		s := ScoredResult{MovieResponse: r, YearDiff: -1}
+		if maxPopularity > 0 {
+			s.RelativePopularity = r.Popularity / maxPopularity
+		}
+		date := r.ReleaseDate
+		if date == "" {
+			date = r.FirstAirDate
+		}
+		if y, err := strconv.Atoi(GetYear(date)); err == nil && year > 0 {
+			s.YearDiff = y - year
+			if s.YearDiff < 0 {
+				s.YearDiff = -s.YearDiff
+			}
+		}
+		if year > 0 {
+			s.Score = popularityWeight * s.RelativePopularity
+			if s.YearDiff >= 0 {
+				s.Score += yearWeight / float64(s.YearDiff+1)
+			}
+		} else {
+			s.Score = s.RelativePopularity
+		}
+		scored[i] = s
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	return scored
+}
+
+// EpisodeGroupResponse 剧集组（替代排序，如绝对顺序、DVD顺序）
+type EpisodeGroupResponse struct {
+	ID     string         `json:"id"`
+	Name   string         `json:"name"`
+	Type   int            `json:"type"`
+	Groups []EpisodeGroup `json:"groups"`
+}
+
+// EpisodeGroup 剧集组中的一个分组，通常对应一季
+type EpisodeGroup struct {
+	Name     string         `json:"name"`
+	Order    int            `json:"order"`
+	Episodes []GroupEpisode `json:"episodes"`
+}
+
+// GroupEpisode 剧集组中的一集
+type GroupEpisode struct {
+	Name          string `json:"name"`
+	Order         int    `json:"order"`          // 在组内的顺序，从0开始
+	SeasonNumber  int    `json:"season_number"`  // 默认排序下的季数
+	EpisodeNumber int    `json:"episode_number"` // 默认排序下的集数
+	AirDate       string `json:"air_date"`
+}
+
+// GetYear 从 2006-01-02 格式的日期中取出年份，无法解析时返回空字符串
+func GetYear(dateStr string) string {
+	if dateStr == "" {
+		return ""
+	}
+	t, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", t.Year())
+}
+
+// runningStatuses 仍在连载的电视剧状态
+var runningStatuses = map[string]bool{
+	"Returning Series": true,
+	"In Production":    true,
+	"Planned":          true,
+	"Pilot":            true,
+}
+
+// EndYear 返回已完结电视剧最后一集播出的年份；仍在连载、没有播出日期或不是电视剧时返回空字符串
+func (m *MovieResponse) EndYear() string {
+	if runningStatuses[m.Status] {
+		return ""
+	}
+	return GetYear(m.LastAirDate)
+}
+
+// MapEpisodeThroughGroup 将文件中的季集（按剧集组排序）映射为TMDB默认排序的季集
+func MapEpisodeThroughGroup(group *EpisodeGroupResponse, season, episode int) (*GroupEpisode, error) {
+	var target *EpisodeGroup
+	for i := range group.Groups {
+		if group.Groups[i].Order == season {
+			target = &group.Groups[i]
+			break
+		}
+	}
+	// 绝对顺序等剧集组通常只有一个分组
+	if target == nil && len(group.Groups) == 1 {
+		target = &group.Groups[0]
+	}
+	if target == nil {
+		return nil, fmt.Errorf("剧集组中没有第%d组", season)
+	}
+
+	for i := range target.Episodes {
+		if target.Episodes[i].Order == episode-1 {
+			return &target.Episodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("剧集组「%s」中没有第%d集", target.Name, episode)
+}
+
+// Trace 不为nil时，每次请求都会输出URL（API密钥已隐藏）、状态码和耗时，便于用curl复现问题
+var Trace io.Writer
+
+// RedactURL 隐藏URL中的api_key参数
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	if query.Has("api_key") {
+		query.Set("api_key", "REDACTED")
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// Client TMDB接口客户端，BaseURL和HTTPClient可以替换，便于测试或使用代理地址
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	Language   string
+	HTTPClient *http.Client
+
+	// notFound 本次运行中返回404的请求路径，同一路径不再重复请求
+	mu       sync.Mutex
+	notFound map[string]*StatusError
+}
+
+// NewClient 使用默认地址和中文语言创建客户端
+func NewClient(apiKey string) *Client {
+	return &Client{
+		BaseURL:    BaseURL,
+		APIKey:     apiKey,
+		Language:   "zh-CN",
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// StatusError TMDB返回了非200的状态码
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API请求失败，状态码: %d，响应: %s", e.StatusCode, e.Body)
+}
+
+// Details 获取电影或电视剧详情，appendTo为附加到响应中的内容，如 AppendReleaseDates
+func (c *Client) Details(ctx context.Context, mediaType string, id int, appendTo ...string) (*MovieResponse, error) {
+	var resp MovieResponse
+	path := fmt.Sprintf("/%s/%d", mediaType, id)
+	if len(appendTo) > 0 {
+		path += "?append_to_response=" + url.QueryEscape(strings.Join(appendTo, ","))
+	}
+	if err := c.Get(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Configuration 请求 /configuration 接口，用于检查API密钥是否有效
+func (c *Client) Configuration(ctx context.Context) error {
+	var resp map[string]interface{}
+	return c.Get(ctx, "/configuration", &resp)
+}
+
+// Episode 获取电视剧某一集的详情
+func (c *Client) Episode(ctx context.Context, tvID, season, episode int) (*EpisodeResponse, error) {
+	var resp EpisodeResponse
+	if err := c.Get(ctx, fmt.Sprintf("/tv/%d/season/%d/episode/%d", tvID, season, episode), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Search 按名称搜索电影或电视剧，返回第一页结果
+func (c *Client) Search(ctx context.Context, mediaType, query string) ([]MovieResponse, error) {
+	var resp SearchResponse
+	if err := c.Get(ctx, "/search/"+mediaType+"?query="+url.QueryEscape(query), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// EpisodeGroup 获取剧集组详情
+func (c *Client) EpisodeGroup(ctx context.Context, id string) (*EpisodeGroupResponse, error) {
+	var resp EpisodeGroupResponse
+	if err := c.Get(ctx, "/tv/episode_group/"+url.PathEscape(id), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Season 获取电视剧某一季的详情
+func (c *Client) Season(ctx context.Context, tvID, season int) (*SeasonResponse, error) {
+	var resp SeasonResponse
+	if err := c.Get(ctx, fmt.Sprintf("/tv/%d/season/%d", tvID, season), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Get 请求BaseURL下的path（可带查询参数），附加API密钥和语言参数，并将响应解析到target；
+// 返回过404的path直接返回上次的错误，不再重复请求
+func (c *Client) Get(ctx context.Context, path string, target interface{}) error {
+	c.mu.Lock()
+	cached := c.notFound[path]
+	c.mu.Unlock()
+	if cached != nil {
+		if Trace != nil {
+			fmt.Fprintf(Trace, "GET %s 此前已返回404，不再请求\n", path)
+		}
+		return cached
+	}
+
+	err := c.get(ctx, path, target)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		c.mu.Lock()
+		if c.notFound == nil {
+			c.notFound = make(map[string]*StatusError)
+		}
+		c.notFound[path] = statusErr
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// NotFound 返回本次运行中TMDB返回404的请求路径（如 /tv/1396），按字母顺序排列
+func (c *Client) NotFound() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	paths := make([]string, 0, len(c.notFound))
+	for path := range c.notFound {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (c *Client) get(ctx context.Context, path string, target interface{}) error {
+	query := url.Values{}
+	query.Set("api_key", c.APIKey)
+	if c.Language != "" {
+		query.Set("language", c.Language)
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return c.fetchJSON(ctx, c.BaseURL+path+sep+query.Encode(), target)
+}
+
+// fetchJSON 请求TMDB接口并将响应解析到target，ctx取消或超时时正在进行的请求会被中断
+func (c *Client) fetchJSON(ctx context.Context, requestURL string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	req.Header.Add("accept", "application/json")
+
+	if Trace != nil {
+		fmt.Fprintf(Trace, "GET %s\n", RedactURL(requestURL))
+	}
+	start := time.Now()
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return errors.New("请求已取消")
+		case errors.Is(err, context.DeadlineExceeded):
+			return fmt.Errorf("请求超时: %s", RedactURL(requestURL))
+		}
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = RedactURL(urlErr.URL) // 错误信息中不显示API密钥
+		}
+		return fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if Trace != nil {
+		fmt.Fprintf(Trace, "状态: %s，耗时: %v\n", resp.Status, time.Since(start).Round(time.Millisecond))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if len(body) == 0 {
+		return errors.New("解析响应失败: 响应为空")
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// SeasonResponse 季详情接口的响应
+type SeasonResponse struct {
+	SeasonNumber int             `json:"season_number"`
+	Episodes     []SeasonEpisode `json:"episodes"`
+}
+
+// SeasonEpisode 季详情中的一集
+type SeasonEpisode struct {
+	EpisodeNumber int    `json:"episode_number"`
+	Name          string `json:"name"`
+	AirDate       string `json:"air_date"`
+}
+
+// EpisodeResponse 单集详情接口的响应
+type EpisodeResponse struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Overview      string `json:"overview"`
+	AirDate       string `json:"air_date"`
+	Runtime       int    `json:"runtime"` // 分钟，未知时为0
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+}
@@ -0,0 +1,366 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient("secret")
+	c.BaseURL = server.URL
+	c.HTTPClient = server.Client()
+	return c
+}
+
+func TestClientDetails(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tv/1396" {
+			t.Errorf("path = %q, want /tv/1396", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api_key"); got != "secret" {
+			t.Errorf("api_key = %q, want secret", got)
+		}
+		if got := r.URL.Query().Get("language"); got != "zh-CN" {
+			t.Errorf("language = %q, want zh-CN", got)
+		}
+		w.Write([]byte(`{"id":1396,"name":"绝命毒师","first_air_date":"2008-01-20"}`))
+	})
+
+	got, err := c.Details(context.Background(), MediaTypeTV, 1396)
+	if err != nil {
+		t.Fatalf("Details() error = %v", err)
+	}
+	if got.ID != 1396 || got.Name != "绝命毒师" || GetYear(got.FirstAirDate) != "2008" {
+		t.Errorf("Details() = %+v", got)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"status_code":7,"status_message":"Invalid API key"}`, "状态码: 401"},
+		{"not found", http.StatusNotFound, `{"status_code":34}`, "状态码: 404"},
+		{"rate limited", http.StatusTooManyRequests, `{"status_code":25}`, "状态码: 429"},
+		{"malformed json", http.StatusOK, `{"id":`, "解析响应失败"},
+		{"empty body", http.StatusOK, "", "响应为空"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := c.Details(context.Background(), MediaTypeMovie, 1)
+			if err == nil {
+				t.Fatal("Details() error = nil, want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Details() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+
+			var statusErr *StatusError
+			if isStatus := errors.As(err, &statusErr); isStatus != (tt.status != http.StatusOK) {
+				t.Errorf("errors.As(*StatusError) = %v for status %d", isStatus, tt.status)
+			} else if isStatus && statusErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", statusErr.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestClientCanceled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Season(ctx, 1, 1); err == nil || !strings.Contains(err.Error(), "请求已取消") {
+		t.Errorf("Season() error = %v, want 请求已取消", err)
+	}
+}
+
+func TestClientSearch(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/tv" {
+			t.Errorf("path = %q, want /search/tv", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("query"); got != "绝命毒师 & co" {
+			t.Errorf("query = %q", got)
+		}
+		if got := r.URL.Query().Get("api_key"); got != "secret" {
+			t.Errorf("api_key = %q, want secret", got)
+		}
+		w.Write([]byte(`{"page":1,"total_results":2,"results":[{"id":1396,"name":"绝命毒师"},{"id":2,"name":"其他"}]}`))
+	})
+
+	got, err := c.Search(context.Background(), MediaTypeTV, "绝命毒师 & co")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 1396 {
+		t.Errorf("Search() = %+v", got)
+	}
+}
+
+func TestEndYear(t *testing.T) {
+	tests := []struct {
+		status, last, want string
+	}{
+		{"Ended", "2013-09-29", "2013"},
+		{"Canceled", "2019-05-01", "2019"},
+		{"Returning Series", "2024-03-01", ""},
+		{"", "2010-01-01", "2010"},
+		{"Ended", "", ""},
+	}
+	for _, tt := range tests {
+		m := &MovieResponse{Status: tt.status, LastAirDate: tt.last}
+		if got := m.EndYear(); got != tt.want {
+			t.Errorf("EndYear() with status %q, last_air_date %q = %q, want %q", tt.status, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestClientNotFoundCache(t *testing.T) {
+	hits := make(map[string]int)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		if r.URL.Path == "/tv/1" {
+			w.Write([]byte(`{"id":1,"name":"剧"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status_code":34}`))
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Details(context.Background(), MediaTypeTV, 999); err == nil || !strings.Contains(err.Error(), "状态码: 404") {
+			t.Fatalf("Details(999) error = %v, want 404", err)
+		}
+		if _, err := c.Details(context.Background(), MediaTypeTV, 1); err != nil {
+			t.Fatalf("Details(1) error = %v", err)
+		}
+	}
+	c.Details(context.Background(), MediaTypeMovie, 999)
+
+	if hits["/tv/999"] != 1 || hits["/tv/1"] != 3 {
+		t.Errorf("hits = %v, want /tv/999 requested once and /tv/1 three times", hits)
+	}
+	if got := c.NotFound(); len(got) != 2 || got[0] != "/movie/999" || got[1] != "/tv/999" {
+		t.Errorf("NotFound() = %q", got)
+	}
+}
+
+func TestMovieDate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("append_to_response"); got != AppendReleaseDates {
+			t.Errorf("append_to_response = %q, want %q", got, AppendReleaseDates)
+		}
+		w.Write([]byte(`{"id":5,"title":"电影","release_date":"2011-01-20","release_dates":{"results":[
+			{"iso_3166_1":"US","release_dates":[{"release_date":"2010-07-16T00:00:00.000Z","type":3},{"release_date":"2010-12-07T00:00:00.000Z","type":4}]},
+			{"iso_3166_1":"GB","release_dates":[{"release_date":"2010-07-08T00:00:00.000Z","type":1},{"release_date":"2010-11-22T00:00:00.000Z","type":4}]}
+		]}}`))
+	})
+
+	m, err := c.Details(context.Background(), MediaTypeMovie, 5, AppendReleaseDates)
+	if err != nil {
+		t.Fatalf("Details() error = %v", err)
+	}
+	tests := []struct {
+		source, want string
+	}{
+		{YearSourceRelease, "2011-01-20"},
+		{YearSourceDigital, "2010-11-22"},
+		{YearSourceEarliest, "2010-07-08"},
+	}
+	for _, tt := range tests {
+		if got := m.MovieDate(tt.source); got != tt.want {
+			t.Errorf("MovieDate(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+
+	// 没有附加 release_dates 或没有数字发行日期时使用 release_date
+	plain := &MovieResponse{ReleaseDate: "2011-01-20"}
+	if got := plain.MovieDate(YearSourceEarliest); got != "2011-01-20" {
+		t.Errorf("MovieDate() without release_dates = %q", got)
+	}
+	m.ReleaseDates.Results = m.ReleaseDates.Results[:0]
+	if got := m.MovieDate(YearSourceDigital); got != "2011-01-20" {
+		t.Errorf("MovieDate() without digital release = %q", got)
+	}
+}
+
+func TestStreamingProvider(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("append_to_response"); got != AppendWatchProviders {
+			t.Errorf("append_to_response = %q, want %q", got, AppendWatchProviders)
+		}
+		w.Write([]byte(`{"id":1,"name":"剧","watch/providers":{"results":{
+			"US":{"flatrate":[{"provider_name":"Hulu","display_priority":5},{"provider_name":"Netflix","display_priority":0}],"buy":[{"provider_name":"Apple TV","display_priority":1}]},
+			"GB":{"buy":[{"provider_name":"Apple TV","display_priority":1}]}
+		}}}`))
+	})
+
+	m, err := c.Details(context.Background(), MediaTypeTV, 1, AppendWatchProviders)
+	if err != nil {
+		t.Fatalf("Details() error = %v", err)
+	}
+	tests := []struct {
+		region, want string
+	}{
+		{"US", "Netflix"},
+		{"us", "Netflix"},
+		{"GB", ""}, // 只能购买，没有订阅渠道
+		{"CN", ""},
+	}
+	for _, tt := range tests {
+		if got := m.StreamingProvider(tt.region); got != tt.want {
+			t.Errorf("StreamingProvider(%q) = %q, want %q", tt.region, got, tt.want)
+		}
+	}
+	if got := (&MovieResponse{}).StreamingProvider("US"); got != "" {
+		t.Errorf("StreamingProvider() without watch/providers = %q, want empty", got)
+	}
+}
+
+func TestRankResults(t *testing.T) {
+	results := []MovieResponse{
+		{ID: 1, Title: "Remake", ReleaseDate: "2005-01-01", Popularity: 80},
+		{ID: 2, Title: "Original", ReleaseDate: "2019-03-01", Popularity: 20},
+		{ID: 3, Name: "TV", FirstAirDate: "2018-05-01", Popularity: 10},
+		{ID: 4, Title: "Unknown"},
+	}
+	ids := func(ranked []ScoredResult) []int {
+		var ids []int
+		for _, r := range ranked {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	ranked := RankResults(results, 2019)
+	if got, want := ids(ranked), []int{2, 1, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("RankResults() with year order = %v, want %v", got, want)
+	}
+	if r := ranked[0]; r.YearDiff != 0 || r.RelativePopularity != 0.25 || r.Score != 0.625 {
+		t.Errorf("RankResults()[0] = %+v, want YearDiff 0, RelativePopularity 0.25, Score 0.625", r)
+	}
+	if r := ranked[3]; r.YearDiff != -1 || r.Score != 0 {
+		t.Errorf("RankResults()[3] = %+v, want YearDiff -1, Score 0", r)
+	}
+
+	// 没有年份时只按热度排序，热度相同时保持原有顺序
+	if got, want := ids(RankResults(results, 0)), []int{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("RankResults() without year order = %v, want %v", got, want)
+	}
+}
+
+func TestMapEpisodeThroughGroup(t *testing.T) {
+	dvd := &EpisodeGroupResponse{Name: "DVD", Groups: []EpisodeGroup{
+		{Name: "Disc 1", Order: 1, Episodes: []GroupEpisode{
+			{Name: "Pilot", Order: 0, SeasonNumber: 1, EpisodeNumber: 1},
+			{Name: "Second", Order: 1, SeasonNumber: 1, EpisodeNumber: 3},
+		}},
+		{Name: "Disc 2", Order: 2, Episodes: []GroupEpisode{
+			{Name: "Third", Order: 0, SeasonNumber: 1, EpisodeNumber: 2},
+		}},
+	}}
+	absolute := &EpisodeGroupResponse{Name: "Absolute", Groups: []EpisodeGroup{
+		{Name: "All", Order: 1, Episodes: []GroupEpisode{
+			{Order: 0, SeasonNumber: 1, EpisodeNumber: 1},
+			{Order: 24, SeasonNumber: 2, EpisodeNumber: 1},
+		}},
+	}}
+
+	tests := []struct {
+		group           *EpisodeGroupResponse
+		season, episode int
+		wantSeason      int
+		wantEpisode     int
+		wantErr         bool
+	}{
+		{dvd, 1, 1, 1, 1, false},
+		{dvd, 1, 2, 1, 3, false},
+		{dvd, 2, 1, 1, 2, false},
+		{dvd, 1, 3, 0, 0, true},
+		{dvd, 3, 1, 0, 0, true},
+		{absolute, 1, 25, 2, 1, false},
+		{absolute, 5, 1, 1, 1, false}, // 只有一个分组时不要求季数一致
+	}
+	for _, tt := range tests {
+		got, err := MapEpisodeThroughGroup(tt.group, tt.season, tt.episode)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("MapEpisodeThroughGroup(%s, %d, %d) = %+v, want error", tt.group.Name, tt.season, tt.episode, got)
+			}
+			continue
+		}
+		if err != nil || got.SeasonNumber != tt.wantSeason || got.EpisodeNumber != tt.wantEpisode {
+			t.Errorf("MapEpisodeThroughGroup(%s, %d, %d) = %+v, %v, want S%dE%d", tt.group.Name, tt.season, tt.episode, got, err, tt.wantSeason, tt.wantEpisode)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.themoviedb.org/3/tv/1?api_key=secret&language=zh-CN", "https://api.themoviedb.org/3/tv/1?api_key=REDACTED&language=zh-CN"},
+		{"https://api.themoviedb.org/3/tv/1?language=zh-CN", "https://api.themoviedb.org/3/tv/1?language=zh-CN"},
+		{"https://api.themoviedb.org/3/search/tv?query=a+b&api_key=secret", "https://api.themoviedb.org/3/search/tv?api_key=REDACTED&query=a+b"},
+	}
+	for _, tt := range tests {
+		if got := RedactURL(tt.url); got != tt.want {
+			t.Errorf("RedactURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestClientErrorsHideAPIKey(t *testing.T) {
+	var trace strings.Builder
+	Trace = &trace
+	t.Cleanup(func() { Trace = nil })
+
+	// 连接失败
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	c := NewClient("secret")
+	c.BaseURL = server.URL
+	_, connErr := c.Details(context.Background(), MediaTypeTV, 1)
+
+	// 请求超时
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, timeoutErr := c.Details(ctx, MediaTypeTV, 1)
+
+	for name, err := range map[string]error{"connection": connErr, "timeout": timeoutErr} {
+		if err == nil {
+			t.Errorf("%s: Details() error = nil, want error", name)
+		} else if strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), "api_key=REDACTED") {
+			t.Errorf("%s: Details() error = %q, want api_key redacted", name, err)
+		}
+	}
+	if strings.Contains(trace.String(), "secret") || !strings.Contains(trace.String(), "api_key=REDACTED") {
+		t.Errorf("Trace = %q, want api_key redacted", trace.String())
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+// 集数校验：对照TMDB季详情，检查文件中的集数是否超出该季的集数，以及对应的剧集是否已经播出
+func validateEpisodes(files []string, seasons map[int]*tmdb.SeasonResponse, opts recognition.ParseOptions, now time.Time) []string {
+	var warnings []string
+	for _, file := range files {
+		name := filepath.Base(file)
+		info := recognition.ParsePath(file, opts)
+		season, err1 := strconv.Atoi(info.Season)
+		episode, err2 := strconv.Atoi(info.Episode)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		data, ok := seasons[season]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s: TMDB中没有第%d季", name, season))
+			continue
+		}
+
+		var found *tmdb.SeasonEpisode
+		for i := range data.Episodes {
+			if data.Episodes[i].EpisodeNumber == episode {
+				found = &data.Episodes[i]
+				break
+			}
+		}
+		if found == nil && episode == 0 {
+			// 第0集是有效的集数，但TMDB通常把试播集放在第0季（特别篇）中
+			warnings = append(warnings, fmt.Sprintf("%s: TMDB第%d季中没有第0集，试播集在TMDB中通常位于第0季（特别篇）", name, season))
+			continue
+		}
+		if found == nil {
+			warnings = append(warnings, fmt.Sprintf("%s: 超出TMDB第%d季的集数（共%d集）", name, season, len(data.Episodes)))
+			continue
+		}
+
+		if found.AirDate == "" {
+			warnings = append(warnings, fmt.Sprintf("%s: S%02dE%02d 播出日期未定，可能是误标或占位文件", name, season, episode))
+			continue
+		}
+		airDate, err := time.Parse("2006-01-02", found.AirDate)
+		if err == nil && airDate.After(now) {
+			warnings = append(warnings, fmt.Sprintf("%s: S%02dE%02d 尚未播出（播出日期 %s），可能是误标或占位文件", name, season, episode, found.AirDate))
+		}
+	}
+	return warnings
+}
+
+// fileSeasons 返回匹配文件中出现的所有季数
+func fileSeasons(files []string, opts recognition.ParseOptions) []int {
+	seen := make(map[int]bool)
+	var seasons []int
+	for _, file := range files {
+		season, err := strconv.Atoi(recognition.ParsePath(file, opts).Season)
+		if err != nil || seen[season] {
+			continue
+		}
+		seen[season] = true
+		seasons = append(seasons, season)
+	}
+	sort.Ints(seasons)
+	return seasons
+}
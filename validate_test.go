@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Harry-zy/custom-recognition/recognition"
+	"github.com/Harry-zy/custom-recognition/tmdb"
+)
+
+func TestValidateEpisodes(t *testing.T) {
+	seasons := map[int]*tmdb.SeasonResponse{
+		1: {SeasonNumber: 1, Episodes: []tmdb.SeasonEpisode{
+			{EpisodeNumber: 1, AirDate: "2020-01-01"},
+			{EpisodeNumber: 2, AirDate: "2020-01-08"},
+			{EpisodeNumber: 3, AirDate: "2020-02-01"},
+			{EpisodeNumber: 4},
+		}},
+	}
+	now := time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		file string
+		want string // 提示中应包含的内容，为空表示没有提示
+	}{
+		{"Show.S01E01.mkv", ""},
+		{"Show.S01E02.mkv", ""},
+		{"Show.S01E00.mkv", "TMDB第1季中没有第0集，试播集在TMDB中通常位于第0季（特别篇）"},
+		{"Show.S01E05.mkv", "超出TMDB第1季的集数（共4集）"},
+		{"Show.S02E01.mkv", "TMDB中没有第2季"},
+		{"Show.S01E03.mkv", "S01E03 尚未播出（播出日期 2020-02-01）"},
+		{"Show.S01E04.mkv", "S01E04 播出日期未定"},
+		{"Show.1080p.mkv", ""},
+	}
+	for _, tt := range tests {
+		warnings := validateEpisodes([]string{tt.file}, seasons, recognition.ParseOptions{}, now)
+		if tt.want == "" {
+			if len(warnings) > 0 {
+				t.Errorf("validateEpisodes(%q) = %q, want no warnings", tt.file, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 || !strings.HasPrefix(warnings[0], tt.file+": ") || !strings.Contains(warnings[0], tt.want) {
+			t.Errorf("validateEpisodes(%q) = %q, want %q", tt.file, warnings, tt.want)
+		}
+	}
+}